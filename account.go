@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/dataexport"
+	"github.com/google/uuid"
+)
+
+const (
+	accountDeletionCoolingOff = 14 * 24 * time.Hour
+	dataExportRetention       = 7 * 24 * time.Hour
+	dataExportStaleAfter      = 15 * time.Minute
+)
+
+const dataExportStatusReady = "ready"
+
+type dataExportResponse struct {
+	Id          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	DownloadUrl string     `json:"download_url,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// handlerDeleteAccount schedules the caller's account for deletion once the
+// cooling-off period has passed. The password is required again so that a
+// stolen access token alone can't remove an account.
+func (cfg *apiConfig) handlerDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Password string `json:"password"`
+	}
+	type response struct {
+		DeletionScheduledFor time.Time `json:"deletion_scheduled_for"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.GetUserById(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := auth.CheckPasswordHash(param.Password, user.HashedPassword); err != nil {
+		writeErrorResponse(w, errors.New("incorrect password"), http.StatusUnauthorized)
+		return
+	}
+
+	if user.DeletionScheduledFor.Valid {
+		writeSuccessResponse(w, response{DeletionScheduledFor: user.DeletionScheduledFor.Time}, http.StatusAccepted)
+		return
+	}
+
+	user, err = cfg.queries.ScheduleUserDeletion(r.Context(),
+		database.ScheduleUserDeletionParams{
+			ID:                   userId,
+			DeletionScheduledFor: sql.NullTime{Time: time.Now().Add(accountDeletionCoolingOff), Valid: true},
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, response{DeletionScheduledFor: user.DeletionScheduledFor.Time}, http.StatusAccepted)
+}
+
+func (cfg *apiConfig) handlerCancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := cfg.queries.CancelUserDeletion(r.Context(), userId); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// runAccountDeletion periodically deletes accounts whose cooling-off period
+// has ended. Their data goes with them through ON DELETE CASCADE.
+func (cfg *apiConfig) runAccountDeletion(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.deleteDueAccounts(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) deleteDueAccounts(ctx context.Context) {
+	deleted, err := cfg.queries.DeleteUsersDueForDeletion(ctx)
+	if err != nil {
+		log.Printf("Error deleting accounts: %s", err)
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("Deleted %d accounts after their cooling-off period", deleted)
+	}
+}
+
+// handlerCreateDataExport queues an export of the caller's data. If one is
+// already queued or running it is returned instead of starting another.
+func (cfg *apiConfig) handlerCreateDataExport(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	export, err := cfg.queries.GetActiveDataExportByUserId(r.Context(), userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		export, err = cfg.queries.CreateDataExport(r.Context(), userId)
+	}
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toDataExportResponse(export), http.StatusAccepted)
+}
+
+func (cfg *apiConfig) handlerGetDataExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := cfg.getOwnedDataExport(w, r)
+	if !ok {
+		return
+	}
+
+	writeSuccessResponse(w, toDataExportResponse(export), http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerDownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := cfg.getOwnedDataExport(w, r)
+	if !ok {
+		return
+	}
+
+	if export.Status != dataExportStatusReady {
+		writeErrorResponse(w, fmt.Errorf("export is %s", export.Status), http.StatusConflict)
+		return
+	}
+
+	if export.ExpiresAt.Valid && export.ExpiresAt.Time.Before(time.Now()) {
+		writeErrorResponse(w, errors.New("export has expired"), http.StatusGone)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"chirpy-export-%s.zip\"", export.ID))
+	w.WriteHeader(http.StatusOK)
+	w.Write(export.Archive)
+}
+
+// getOwnedDataExport loads the export named by the {id} path value if it
+// belongs to the caller. It writes the error response itself.
+func (cfg *apiConfig) getOwnedDataExport(w http.ResponseWriter, r *http.Request) (database.DataExport, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.DataExport{}, false
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.DataExport{}, false
+	}
+
+	idStr := r.PathValue("id")
+	exportId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return database.DataExport{}, false
+	}
+
+	export, err := cfg.queries.GetDataExportById(r.Context(),
+		database.GetDataExportByIdParams{
+			ID:     exportId,
+			UserID: userId,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("export not found"), http.StatusNotFound)
+			return database.DataExport{}, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.DataExport{}, false
+	}
+
+	return export, true
+}
+
+// runDataExportWorker builds queued exports and removes expired archives.
+func (cfg *apiConfig) runDataExportWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.processDataExports(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) processDataExports(ctx context.Context) {
+	if _, err := cfg.queries.DeleteExpiredDataExports(ctx); err != nil {
+		log.Printf("Error deleting expired data exports: %s", err)
+	}
+
+	for {
+		export, err := cfg.queries.ClaimDataExport(ctx, time.Now().Add(-dataExportStaleAfter))
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("Error claiming data export: %s", err)
+			}
+			return
+		}
+
+		archive, err := cfg.buildDataExport(ctx, export.UserID)
+		if err != nil {
+			log.Printf("Error building data export %s: %s", export.ID, err)
+			if err := cfg.queries.FailDataExport(ctx,
+				database.FailDataExportParams{
+					ID:    export.ID,
+					Error: err.Error(),
+				},
+			); err != nil {
+				log.Printf("Error recording failed data export %s: %s", export.ID, err)
+			}
+			continue
+		}
+
+		err = cfg.queries.CompleteDataExport(ctx,
+			database.CompleteDataExportParams{
+				ID:        export.ID,
+				Archive:   archive,
+				ExpiresAt: sql.NullTime{Time: time.Now().Add(dataExportRetention), Valid: true},
+			},
+		)
+		if err != nil {
+			log.Printf("Error storing data export %s: %s", export.ID, err)
+		}
+	}
+}
+
+// buildDataExport collects everything the user has created or that
+// describes their account into a zip archive of JSON files.
+func (cfg *apiConfig) buildDataExport(ctx context.Context, userId uuid.UUID) ([]byte, error) {
+	type profile struct {
+		Id          uuid.UUID `json:"id"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+		Email       string    `json:"email"`
+		IsChirpyRed bool      `json:"is_chirpy_red"`
+		Role        string    `json:"role"`
+		DmPolicy    string    `json:"dm_policy"`
+		IsPrivate   bool      `json:"is_private"`
+	}
+	type follow struct {
+		FollowerId uuid.UUID `json:"follower_id"`
+		FolloweeId uuid.UUID `json:"followee_id"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+	type message struct {
+		Id             uuid.UUID `json:"id"`
+		CreatedAt      time.Time `json:"created_at"`
+		ConversationId uuid.UUID `json:"conversation_id"`
+		Body           string    `json:"body"`
+	}
+	type bookmark struct {
+		ChirpId   uuid.UUID `json:"chirp_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	type collection struct {
+		collectionResponse
+		ChirpIds []uuid.UUID `json:"chirp_ids"`
+	}
+	type list struct {
+		listResponse
+		MemberIds []uuid.UUID `json:"member_ids"`
+	}
+	// Sessions never include the refresh token itself.
+	type session struct {
+		CreatedAt time.Time  `json:"created_at"`
+		ExpiresAt time.Time  `json:"expires_at"`
+		RevokedAt *time.Time `json:"revoked_at"`
+	}
+
+	user, err := cfg.queries.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	chirps, err := cfg.queries.GetAllChirpsByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	follows, err := cfg.queries.GetFollowsByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	followExports := make([]follow, 0, len(follows))
+	for _, f := range follows {
+		followExports = append(followExports, follow{FollowerId: f.FollowerID, FolloweeId: f.FolloweeID, CreatedAt: f.CreatedAt})
+	}
+
+	blocks, err := cfg.queries.GetBlocksByBlockerId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	blockExports := make([]relationResponse, 0, len(blocks))
+	for _, b := range blocks {
+		blockExports = append(blockExports, relationResponse{UserId: b.BlockedID, CreatedAt: b.CreatedAt})
+	}
+
+	mutes, err := cfg.queries.GetMutesByMuterId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	muteExports := make([]relationResponse, 0, len(mutes))
+	for _, m := range mutes {
+		muteExports = append(muteExports, relationResponse{UserId: m.MutedID, CreatedAt: m.CreatedAt})
+	}
+
+	bookmarks, err := cfg.queries.GetBookmarksByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	bookmarkExports := make([]bookmark, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		bookmarkExports = append(bookmarkExports, bookmark{ChirpId: b.ChirpID, CreatedAt: b.CreatedAt})
+	}
+
+	collections, err := cfg.queries.GetCollectionsByUserId(ctx,
+		database.GetCollectionsByUserIdParams{
+			UserID:     userId,
+			PublicOnly: false,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	items, err := cfg.queries.GetCollectionItemsByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	itemIds := make(map[uuid.UUID][]uuid.UUID)
+	for _, item := range items {
+		itemIds[item.CollectionID] = append(itemIds[item.CollectionID], item.ChirpID)
+	}
+	collectionExports := make([]collection, 0, len(collections))
+	for _, c := range collections {
+		chirpIds := itemIds[c.ID]
+		if chirpIds == nil {
+			chirpIds = []uuid.UUID{}
+		}
+		collectionExports = append(collectionExports, collection{collectionResponse: toCollectionResponse(c), ChirpIds: chirpIds})
+	}
+
+	lists, err := cfg.queries.GetListsByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	listExports := make([]list, 0, len(lists))
+	for _, l := range lists {
+		members, err := cfg.queries.GetListMembers(ctx, l.ID)
+		if err != nil {
+			return nil, err
+		}
+		memberIds := make([]uuid.UUID, 0, len(members))
+		for _, m := range members {
+			memberIds = append(memberIds, m.MemberID)
+		}
+		listExports = append(listExports, list{listResponse: toListResponse(l), MemberIds: memberIds})
+	}
+
+	messages, err := cfg.queries.GetMessagesBySenderId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	messageExports := make([]message, 0, len(messages))
+	for _, m := range messages {
+		messageExports = append(messageExports, message{Id: m.ID, CreatedAt: m.CreatedAt, ConversationId: m.ConversationID, Body: m.Body})
+	}
+
+	tokens, err := cfg.queries.GetRefreshTokensByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	sessionExports := make([]session, 0, len(tokens))
+	for _, t := range tokens {
+		export := session{CreatedAt: t.CreatedAt, ExpiresAt: t.ExpiresAt}
+		if t.RevokedAt.Valid {
+			export.RevokedAt = &t.RevokedAt.Time
+		}
+		sessionExports = append(sessionExports, export)
+	}
+
+	return dataexport.Archive([]dataexport.File{
+		{Name: "profile.json", Data: profile{
+			Id:          user.ID,
+			CreatedAt:   user.CreatedAt,
+			UpdatedAt:   user.UpdatedAt,
+			Email:       user.Email,
+			IsChirpyRed: user.IsChirpyRed,
+			Role:        user.Role,
+			DmPolicy:    user.DmPolicy,
+			IsPrivate:   user.IsPrivate,
+		}},
+		{Name: "chirps.json", Data: dataexport.Chirps(chirps)},
+		{Name: "follows.json", Data: followExports},
+		{Name: "blocks.json", Data: blockExports},
+		{Name: "mutes.json", Data: muteExports},
+		{Name: "bookmarks.json", Data: bookmarkExports},
+		{Name: "collections.json", Data: collectionExports},
+		{Name: "lists.json", Data: listExports},
+		{Name: "messages.json", Data: messageExports},
+		{Name: "sessions.json", Data: sessionExports},
+	})
+}
+
+func toDataExportResponse(export database.DataExport) dataExportResponse {
+	res := dataExportResponse{
+		Id:        export.ID,
+		CreatedAt: export.CreatedAt,
+		Status:    export.Status,
+		Error:     export.Error,
+	}
+	if export.CompletedAt.Valid {
+		res.CompletedAt = &export.CompletedAt.Time
+	}
+	if export.ExpiresAt.Valid {
+		res.ExpiresAt = &export.ExpiresAt.Time
+	}
+	if export.Status == dataExportStatusReady {
+		res.DownloadUrl = fmt.Sprintf("/api/users/me/exports/%s/download", export.ID)
+	}
+	return res
+}
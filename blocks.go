@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+type relationResponse struct {
+	UserId    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handlerBlockUser blocks another user. Blocking is mutual invisibility, so
+// any follow, follow request or list membership between the two users, in
+// either direction, is removed with it.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	targetId, ok := cfg.getRelationTarget(w, r, userId, "block")
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	_, err = qtx.BlockUser(r.Context(),
+		database.BlockUserParams{
+			BlockerID: userId,
+			BlockedID: targetId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.RemoveFollowsBetween(r.Context(),
+		database.RemoveFollowsBetweenParams{
+			UserA: userId,
+			UserB: targetId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.RemoveFollowRequestsBetween(r.Context(),
+		database.RemoveFollowRequestsBetweenParams{
+			UserA: userId,
+			UserB: targetId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.RemoveListMembershipsBetween(r.Context(),
+		database.RemoveListMembershipsBetweenParams{
+			UserA: userId,
+			UserB: targetId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	targetId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.queries.UnblockUser(r.Context(),
+		database.UnblockUserParams{
+			BlockerID: userId,
+			BlockedID: targetId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	blocks, err := cfg.queries.GetBlocksByBlockerId(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]relationResponse, 0, len(blocks))
+	for _, block := range blocks {
+		responses = append(responses, relationResponse{
+			UserId:    block.BlockedID,
+			CreatedAt: block.CreatedAt,
+		})
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+// handlerMuteUser hides another user's chirps from the caller's timeline and
+// silences their notifications. Unlike a block, the muted user is not told
+// and can still see and interact with the caller.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	targetId, ok := cfg.getRelationTarget(w, r, userId, "mute")
+	if !ok {
+		return
+	}
+
+	_, err = cfg.queries.MuteUser(r.Context(),
+		database.MuteUserParams{
+			MuterID: userId,
+			MutedID: targetId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	targetId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.queries.UnmuteUser(r.Context(),
+		database.UnmuteUserParams{
+			MuterID: userId,
+			MutedID: targetId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	mutes, err := cfg.queries.GetMutesByMuterId(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]relationResponse, 0, len(mutes))
+	for _, mute := range mutes {
+		responses = append(responses, relationResponse{
+			UserId:    mute.MutedID,
+			CreatedAt: mute.CreatedAt,
+		})
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+// getRelationTarget parses the user id in the path and checks that it names
+// some other existing user. It writes the error response itself.
+func (cfg *apiConfig) getRelationTarget(w http.ResponseWriter, r *http.Request, userId uuid.UUID, action string) (uuid.UUID, bool) {
+	idStr := r.PathValue("id")
+	targetId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	if targetId == userId {
+		writeErrorResponse(w, fmt.Errorf("cannot %s yourself", action), http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	if _, err := cfg.queries.GetUserById(r.Context(), targetId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("user not found"), http.StatusNotFound)
+			return uuid.Nil, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return uuid.Nil, false
+	}
+
+	return targetId, true
+}
+
+// getOptionalViewer returns the caller on endpoints that work without
+// authentication. A missing Authorization header means an anonymous viewer;
+// a header carrying a bad token is still an error.
+func (cfg *apiConfig) getOptionalViewer(r *http.Request) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: userId, Valid: true}, nil
+}
+
+// isBlockedEitherWay reports whether either user has blocked the other.
+func isBlockedEitherWay(ctx context.Context, q *database.Queries, userA, userB uuid.UUID) (bool, error) {
+	if userA == userB {
+		return false, nil
+	}
+	return q.IsBlockedEitherWay(ctx,
+		database.IsBlockedEitherWayParams{
+			UserA: userA,
+			UserB: userB,
+		},
+	)
+}
+
+// audienceFilter is a snapshot of who a viewer should not see, used by the
+// live stream and gateway where filtering can't be pushed into a query. The
+// zero value is the filter for an anonymous viewer.
+type audienceFilter struct {
+	viewerID  uuid.NullUUID
+	blocked   map[uuid.UUID]bool
+	muted     map[uuid.UUID]bool
+	following map[uuid.UUID]bool
+}
+
+func loadAudienceFilter(ctx context.Context, q *database.Queries, viewerID uuid.UUID) (audienceFilter, error) {
+	filter := audienceFilter{
+		viewerID:  uuid.NullUUID{UUID: viewerID, Valid: true},
+		blocked:   make(map[uuid.UUID]bool),
+		muted:     make(map[uuid.UUID]bool),
+		following: make(map[uuid.UUID]bool),
+	}
+
+	blocked, err := q.GetBlockRelatedUserIds(ctx, viewerID)
+	if err != nil {
+		return filter, err
+	}
+	for _, id := range blocked {
+		filter.blocked[id] = true
+	}
+
+	muted, err := q.GetMutedUserIds(ctx, viewerID)
+	if err != nil {
+		return filter, err
+	}
+	for _, id := range muted {
+		filter.muted[id] = true
+	}
+
+	following, err := q.GetFolloweeIds(ctx, viewerID)
+	if err != nil {
+		return filter, err
+	}
+	for _, id := range following {
+		filter.following[id] = true
+	}
+
+	return filter, nil
+}
+
+// hides reports whether a chirp event should be withheld: its author is
+// blocked, muted on the timeline, or the viewer isn't in the chirp's
+// audience. Mutes and unlisted chirps only drop out of the timeline, not out
+// of an author the viewer asked for explicitly.
+func (f audienceFilter) hides(event database.ChirpEvent, timeline bool) bool {
+	if f.blocked[event.UserID] || (timeline && f.muted[event.UserID]) {
+		return true
+	}
+	if f.viewerID.Valid && f.viewerID.UUID == event.UserID {
+		return false
+	}
+
+	switch event.Visibility {
+	case visibilityPublic:
+		return false
+	case visibilityUnlisted:
+		return timeline
+	case visibilityFollowers:
+		return !f.following[event.UserID]
+	default:
+		return true
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerBookmarkChirp bookmarks a chirp the caller can see. Bookmarks are
+// private to their owner.
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	chirpId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	_, err = getVisibleChirp(r.Context(), cfg.queries, uuid.NullUUID{UUID: userId, Valid: true}, chirpId)
+	if err != nil {
+		if errors.Is(err, errChirpNotFound) {
+			writeErrorResponse(w, err, http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	_, err = cfg.queries.CreateBookmark(r.Context(),
+		database.CreateBookmarkParams{
+			UserID:  userId,
+			ChirpID: chirpId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	chirpId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.queries.DeleteBookmark(r.Context(),
+		database.DeleteBookmarkParams{
+			UserID:  userId,
+			ChirpID: chirpId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerGetBookmarks pages through the caller's bookmarks, newest first.
+// The before cursor is the previous page's next_cursor.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	limit, err := parseChirpPageLimit(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	before, err := parsePageCursor(r, "before")
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.GetBookmarkedChirpsParams{
+		ViewerID: uuid.NullUUID{UUID: userId, Valid: true},
+		RowLimit: int32(limit),
+	}
+	if before != nil {
+		params.BeforeTime = sql.NullTime{Time: time.UnixMicro(before.Key), Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: before.ID, Valid: true}
+	}
+
+	rows, err := cfg.queries.GetBookmarkedChirps(r.Context(), params)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, database.Chirp{
+			ID:         row.ID,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+			Body:       row.Body,
+			UserID:     row.UserID,
+			HiddenAt:   row.HiddenAt,
+			Visibility: row.Visibility,
+		})
+	}
+
+	page := toChirpPageResponse(chirps, limit)
+	if page.NextCursor != nil {
+		last := rows[len(rows)-1]
+		cursor := pageCursor{Key: last.BookmarkedAt.UnixMicro(), ID: last.ID}.String()
+		page.NextCursor = &cursor
+	}
+	if err := attachChirpDetails(r.Context(), cfg.queries, uuid.NullUUID{UUID: userId, Valid: true}, page.Chirps); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, page, http.StatusOK)
+}
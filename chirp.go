@@ -1,28 +1,54 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/chirptext"
 	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/dberr"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultChirpPageLimit = 20
+	maxChirpPageLimit     = 100
+)
+
+type chirpResponse struct {
+	Id           uuid.UUID             `json:"id"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	Body         string                `json:"body"`
+	UserId       uuid.UUID             `json:"user_id"`
+	Visibility   string                `json:"visibility"`
+	Pinned       bool                  `json:"pinned,omitempty"`
+	Poll         *pollResponse         `json:"poll,omitempty"`
+	LinkPreviews []linkPreviewResponse `json:"link_previews,omitempty"`
+}
+
+// chirpPageResponse is one page of a keyset-paginated chirp listing.
+// NextCursor is set when there may be more.
+type chirpPageResponse struct {
+	Chirps     []chirpResponse `json:"chirps"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameter struct {
-		Body string `json:"body"`
-	}
-	type response struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+		Body        string         `json:"body"`
+		Visibility  string         `json:"visibility" validate:"oneof=public unlisted followers private"`
+		ScheduledAt *time.Time     `json:"scheduled_at"`
+		Poll        *pollParameter `json:"poll"`
 	}
 
 	token, err := auth.GetBearerToken(r.Header)
@@ -31,9 +57,8 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
 	param := parameter{}
-	if err := decoder.Decode(&param); err != nil {
+	if err := decodeJSON(w, r, &param); err != nil {
 		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
@@ -44,39 +69,89 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if len(param.Body) > 140 {
-		writeErrorResponse(w, errors.New("Chirp is too long"), http.StatusBadRequest)
+	body, err := validateChirpBody(param.Body)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
-	chirp, err := cfg.queries.CreateChirp(r.Context(),
-		database.CreateChirpParams{
-			UserID: userId,
-			Body:   filterMessage(param.Body),
-		},
-	)
+	visibility, err := parseVisibility(param.Visibility)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusConflict)
+		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
-	res := response{
-		Id:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
+	if param.Poll != nil {
+		if param.ScheduledAt != nil {
+			writeErrorResponse(w, errors.New("chirps with polls can't be scheduled"), http.StatusBadRequest)
+			return
+		}
+
+		poll, err := parsePoll(*param.Poll)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+		param.Poll = &poll
 	}
-	writeSuccessResponse(w, res, http.StatusCreated)
+
+	// A scheduled chirp is stored as a draft until the scheduler publishes it.
+	if param.ScheduledAt != nil {
+		if err := validateScheduledAt(*param.ScheduledAt); err != nil {
+			writeErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		draft, err := cfg.queries.CreateDraft(r.Context(),
+			database.CreateDraftParams{
+				UserID:      userId,
+				Body:        body,
+				ScheduledAt: sql.NullTime{Time: *param.ScheduledAt, Valid: true},
+				Visibility:  visibility,
+			},
+		)
+		if err != nil {
+			writeErrorResponse(w, err, dberr.Status(err))
+			return
+		}
+
+		writeSuccessResponse(w, toDraftResponse(draft), http.StatusAccepted)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	chirp, err := createChirp(r.Context(), qtx, userId, body, visibility, param.Poll)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	responses := []chirpResponse{toChirpResponse(chirp)}
+	if err := attachChirpDetails(r.Context(), qtx, uuid.NullUUID{UUID: userId, Valid: true}, responses); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	writeSuccessResponse(w, responses[0], http.StatusCreated)
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
-	type response struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+	viewerID, err := cfg.getOptionalViewer(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
 	}
 
 	authorIDParam := r.URL.Query().Get("author_id")
@@ -87,9 +162,9 @@ func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 
 	var (
 		chirps      []database.Chirp
-		err         error
 		authorID    uuid.UUID
 		hasAuthorID bool
+		firstPage   bool
 	)
 
 	if authorIDParam != "" {
@@ -102,54 +177,157 @@ func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 		hasAuthorID = true
 	}
 
+	includePinned := r.URL.Query().Get("include_pinned") == "true"
+	if includePinned && !hasAuthorID {
+		writeErrorResponse(w, errors.New("include_pinned requires author_id"), http.StatusBadRequest)
+		return
+	}
+
+	// The feed predates pagination, so without limit or a cursor it still
+	// returns every chirp. Paging is opt-in, and the next cursor comes back
+	// in a header so the body stays a plain array either way.
+	query := r.URL.Query()
+	paginated := query.Has("limit") || query.Has("after") || query.Has("before")
+	limit, err := parseChirpPageLimit(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	rowLimit := sql.NullInt32{Int32: int32(limit), Valid: true}
+	if !paginated {
+		limit = 0
+		rowLimit = sql.NullInt32{}
+	}
+
 	switch sortParam {
 	case "asc":
+		var after *pageCursor
+		if after, err = parsePageCursor(r, "after"); err != nil {
+			writeErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+		var afterTime sql.NullTime
+		var afterID uuid.NullUUID
+		if after != nil {
+			afterTime = sql.NullTime{Time: time.UnixMicro(after.Key), Valid: true}
+			afterID = uuid.NullUUID{UUID: after.ID, Valid: true}
+		}
+
 		if hasAuthorID {
-			chirps, err = cfg.queries.GetChirpsByAuthorId(r.Context(), authorID)
+			chirps, err = cfg.queries.GetChirpsByAuthorId(r.Context(),
+				database.GetChirpsByAuthorIdParams{
+					UserID:    authorID,
+					ViewerID:  viewerID,
+					AfterTime: afterTime,
+					AfterID:   afterID,
+					RowLimit:  rowLimit,
+				},
+			)
 		} else {
-			chirps, err = cfg.queries.GetChirps(r.Context())
+			chirps, err = cfg.queries.GetChirps(r.Context(),
+				database.GetChirpsParams{
+					ViewerID:  viewerID,
+					AfterTime: afterTime,
+					AfterID:   afterID,
+					RowLimit:  rowLimit,
+				},
+			)
 		}
+		firstPage = after == nil
 	case "desc":
+		var before *pageCursor
+		if before, err = parsePageCursor(r, "before"); err != nil {
+			writeErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+		var beforeTime sql.NullTime
+		var beforeID uuid.NullUUID
+		if before != nil {
+			beforeTime = sql.NullTime{Time: time.UnixMicro(before.Key), Valid: true}
+			beforeID = uuid.NullUUID{UUID: before.ID, Valid: true}
+		}
+
 		if hasAuthorID {
-			chirps, err = cfg.queries.GetChirpsByAuthorIdDesc(r.Context(), authorID)
+			chirps, err = cfg.queries.GetChirpsByAuthorIdDesc(r.Context(),
+				database.GetChirpsByAuthorIdDescParams{
+					UserID:     authorID,
+					ViewerID:   viewerID,
+					BeforeTime: beforeTime,
+					BeforeID:   beforeID,
+					RowLimit:   rowLimit,
+				},
+			)
 		} else {
-			chirps, err = cfg.queries.GetChirpsDesc(r.Context())
+			chirps, err = cfg.queries.GetChirpsDesc(r.Context(),
+				database.GetChirpsDescParams{
+					ViewerID:   viewerID,
+					BeforeTime: beforeTime,
+					BeforeID:   beforeID,
+					RowLimit:   rowLimit,
+				},
+			)
 		}
+		firstPage = before == nil
 	default:
 		writeErrorResponse(w, fmt.Errorf("invalid sort value %q", sortParam), http.StatusBadRequest)
 		return
 	}
 
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			writeErrorResponse(w, fmt.Errorf("chirp not found"), http.StatusNotFound)
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	page := toChirpPageResponse(chirps, limit)
+
+	// Pinned chirps lead the first page, most recently pinned first, and
+	// aren't repeated in the chronological part of any page. The cursor
+	// still comes from the last chronological chirp, so skipping them
+	// doesn't end pagination early.
+	if includePinned {
+		pinned, err := cfg.queries.GetPinnedChirpsByUserId(r.Context(),
+			database.GetPinnedChirpsByUserIdParams{
+				UserID:   authorID,
+				ViewerID: viewerID,
+			},
+		)
+		if err != nil {
+			writeErrorResponse(w, err, dberr.Status(err))
 			return
 		}
-		writeErrorResponse(w, err, http.StatusConflict)
-		return
+
+		pinnedIDs := make(map[uuid.UUID]bool, len(pinned))
+		responses := make([]chirpResponse, 0, len(pinned)+len(page.Chirps))
+		for _, chirp := range pinned {
+			pinnedIDs[chirp.ID] = true
+			if !firstPage {
+				continue
+			}
+			res := toChirpResponse(chirp)
+			res.Pinned = true
+			responses = append(responses, res)
+		}
+		for _, res := range page.Chirps {
+			if !pinnedIDs[res.Id] {
+				responses = append(responses, res)
+			}
+		}
+		page.Chirps = responses
 	}
 
-	responses := make([]response, 0, len(chirps))
-	for _, chirp := range chirps {
-		responses = append(responses, response{
-			Id:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserId:    chirp.UserID,
-		})
+	if err := attachChirpDetails(r.Context(), cfg.queries, viewerID, page.Chirps); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
 	}
 
-	writeSuccessResponse(w, responses, http.StatusOK)
+	writeChirpList(w, page)
 }
 
 func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
-	type response struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+	viewerID, err := cfg.getOptionalViewer(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
 	}
 
 	idStr := r.PathValue("id")
@@ -159,76 +337,302 @@ func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chirp, err := cfg.queries.GetChirpById(r.Context(), id)
+	chirp, err := getVisibleChirp(r.Context(), cfg.queries, viewerID, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			writeErrorResponse(w, fmt.Errorf("chirp not found"), http.StatusNotFound)
+		if errors.Is(err, errChirpNotFound) {
+			writeErrorResponse(w, err, http.StatusNotFound)
 			return
 		}
-		writeErrorResponse(w, err, http.StatusInternalServerError)
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
-	res := response{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	responses := []chirpResponse{toChirpResponse(chirp)}
+	if err := attachChirpDetails(r.Context(), cfg.queries, viewerID, responses); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
 	}
-	writeSuccessResponse(w, res, http.StatusOK)
+
+	writeSuccessResponse(w, responses[0], http.StatusOK)
 }
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
-	type response struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+	chirp, ok := cfg.getOwnChirp(w, r, cfg.queries)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	err = qtx.DeleteChirp(
+		r.Context(),
+		chirp.ID,
+	)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if err := publishChirpEvent(r.Context(), qtx, webhookEventChirpDeleted, chirp, toChirpResponse(chirp)); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	writeSuccessResponse(w, struct{}{}, http.StatusNoContent)
+}
+
+// getOwnChirp loads the chirp named by the {chirpID} path value and checks
+// that the caller wrote it. It writes the error response itself.
+func (cfg *apiConfig) getOwnChirp(w http.ResponseWriter, r *http.Request, q *database.Queries) (database.Chirp, bool) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		writeErrorResponse(w, err, http.StatusUnauthorized)
-		return
+		return database.Chirp{}, false
 	}
 
 	userId, err := auth.ValidateJWT(token, cfg.secret)
 	if err != nil {
 		writeErrorResponse(w, err, http.StatusUnauthorized)
-		return
+		return database.Chirp{}, false
 	}
 
 	chirpIDStr := r.PathValue("chirpID")
 	chirpID, err := uuid.Parse(chirpIDStr)
 	if err != nil {
 		writeErrorResponse(w, err, http.StatusBadRequest)
-		return
+		return database.Chirp{}, false
 	}
 
-	chirp, err := cfg.queries.GetChirpById(
-		r.Context(),
-		chirpID,
-	)
+	chirp, err := q.GetChirpById(r.Context(), chirpID)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusNotFound)
-		return
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, errChirpNotFound, http.StatusNotFound)
+			return database.Chirp{}, false
+		}
+		writeErrorResponse(w, err, dberr.Status(err))
+		return database.Chirp{}, false
 	}
 
 	if chirp.UserID != userId {
 		writeErrorResponse(w, errors.New("chirp does not belong to user"), http.StatusForbidden)
-		return
+		return database.Chirp{}, false
 	}
 
-	err = cfg.queries.DeleteChirp(
-		r.Context(),
-		chirp.ID,
+	return chirp, true
+}
+
+// publishChirpEvent records a chirp change for the live stream and queues it
+// for outbound webhooks, using the caller's transaction-scoped queries.
+// Webhooks for anything but public chirps only go to the author's own
+// subscriptions.
+func publishChirpEvent(ctx context.Context, q *database.Queries, event string, chirp database.Chirp, data any) error {
+	audience, err := recordChirpEvent(ctx, q, event, chirp, data)
+	if err != nil {
+		return err
+	}
+
+	recipient := uuid.NullUUID{}
+	if audience != visibilityPublic {
+		recipient = uuid.NullUUID{UUID: chirp.UserID, Valid: true}
+	}
+	return enqueueWebhookEvent(ctx, q, event, recipient, data)
+}
+
+// recordChirpEvent records a chirp change for the live stream only and
+// returns the audience it was recorded with.
+func recordChirpEvent(ctx context.Context, q *database.Queries, event string, chirp database.Chirp, data any) (string, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	// Events record the effective audience so streams also respect the
+	// author's account privacy at the time.
+	audience, err := chirpAudience(ctx, q, chirp)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = q.CreateChirpEvent(ctx,
+		database.CreateChirpEventParams{
+			Event:      event,
+			ChirpID:    chirp.ID,
+			UserID:     chirp.UserID,
+			Payload:    payload,
+			Visibility: audience,
+		},
 	)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusNotFound)
-		return
+		return "", err
 	}
+	return audience, nil
+}
 
-	writeSuccessResponse(w, struct{}{}, http.StatusNoContent)
+// createChirp inserts a chirp, with its poll if poll is non-nil, queues its
+// links for previews, and announces it: the chirp.created event for streams
+// and webhooks, and notifications for anyone mentioned. The caller owns the
+// transaction.
+func createChirp(ctx context.Context, q *database.Queries, userId uuid.UUID, body, visibility string, poll *pollParameter) (database.Chirp, error) {
+	chirp, err := q.CreateChirp(ctx,
+		database.CreateChirpParams{
+			UserID:     userId,
+			Body:       filterMessage(body),
+			Visibility: visibility,
+		},
+	)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	res := []chirpResponse{toChirpResponse(chirp)}
+	if poll != nil {
+		if err := createPoll(ctx, q, chirp.ID, *poll); err != nil {
+			return database.Chirp{}, err
+		}
+		if err := attachPolls(ctx, q, uuid.NullUUID{}, res); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	if err := queueLinkPreviews(ctx, q, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := publishChirpEvent(ctx, q, webhookEventChirpCreated, chirp, res[0]); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := notifyMentions(ctx, q, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, nil
+}
+
+// validateChirpBody normalizes a chirp body and checks it against the
+// length and content rules, returning the body to store. Every rule it
+// breaks is reported as a field error on body.
+func validateChirpBody(body string) (string, error) {
+	body = chirptext.Normalize(body)
+
+	violations := chirptext.Validate(body)
+	if len(violations) == 0 {
+		return body, nil
+	}
+
+	errs := make([]fieldError, 0, len(violations))
+	for _, v := range violations {
+		errs = append(errs, fieldError{Field: "body", Code: v.Code, Message: v.Message})
+	}
+	return "", newValidationError(errs...)
+}
+
+// attachChirpDetails fills in what a chirp response carries beyond the chirp
+// row itself: its poll, as seen by viewerID, and its link previews.
+func attachChirpDetails(ctx context.Context, q *database.Queries, viewerID uuid.NullUUID, chirps []chirpResponse) error {
+	if err := attachPolls(ctx, q, viewerID, chirps); err != nil {
+		return err
+	}
+	return attachLinkPreviews(ctx, q, chirps)
+}
+
+func toChirpResponse(chirp database.Chirp) chirpResponse {
+	return chirpResponse{
+		Id:         chirp.ID,
+		CreatedAt:  chirp.CreatedAt,
+		UpdatedAt:  chirp.UpdatedAt,
+		Body:       chirp.Body,
+		UserId:     chirp.UserID,
+		Visibility: chirp.Visibility,
+	}
+}
+
+func parseChirpPageLimit(r *http.Request) (int, error) {
+	limitParam := r.URL.Query().Get("limit")
+	if limitParam == "" {
+		return defaultChirpPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 1 || limit > maxChirpPageLimit {
+		return 0, fmt.Errorf("invalid limit %q", limitParam)
+	}
+	return limit, nil
+}
+
+// toChirpPageResponse builds a page whose next cursor is the last chirp's
+// (created_at, id). Pages sorted on another key replace NextCursor. A limit
+// of 0 means the query wasn't limited, so there is no next page.
+func toChirpPageResponse(chirps []database.Chirp, limit int) chirpPageResponse {
+	res := chirpPageResponse{
+		Chirps: make([]chirpResponse, 0, len(chirps)),
+	}
+	for _, chirp := range chirps {
+		res.Chirps = append(res.Chirps, toChirpResponse(chirp))
+	}
+	if limit > 0 && len(chirps) == limit {
+		last := chirps[len(chirps)-1]
+		cursor := pageCursor{Key: last.CreatedAt.UnixMicro(), ID: last.ID}.String()
+		res.NextCursor = &cursor
+	}
+	return res
+}
+
+// writeChirpList writes page as a plain array of chirps, the shape the main
+// feed has always had, with the next cursor in the nextCursorHeader header.
+func writeChirpList(w http.ResponseWriter, page chirpPageResponse) {
+	if page.NextCursor != nil {
+		w.Header().Set(nextCursorHeader, *page.NextCursor)
+	}
+	writeSuccessResponse(w, page.Chirps, http.StatusOK)
+}
+
+// pageCursor is an opaque keyset cursor: the sort key of the last row on a
+// page and its id as a tie-breaker. Carrying the key itself, rather than
+// looking it up by id, keeps the cursor valid after that row is removed.
+type pageCursor struct {
+	Key int64
+	ID  uuid.UUID
+}
+
+func (c pageCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%s", c.Key, c.ID))
+}
+
+// parsePageCursor reads an optional cursor written by pageCursor.String.
+func parsePageCursor(r *http.Request, name string) (*pageCursor, error) {
+	cursorParam := r.URL.Query().Get(name)
+	if cursorParam == "" {
+		return nil, nil
+	}
+
+	invalid := fmt.Errorf("invalid %s %q", name, cursorParam)
+	raw, err := base64.RawURLEncoding.DecodeString(cursorParam)
+	if err != nil {
+		return nil, invalid
+	}
+	keyStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, invalid
+	}
+	key, err := strconv.ParseInt(keyStr, 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, invalid
+	}
+	return &pageCursor{Key: key, ID: id}, nil
 }
@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxCollectionNameLength        = 100
+	maxCollectionDescriptionLength = 500
+)
+
+type collectionResponse struct {
+	Id          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UserId      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsPublic    bool      `json:"is_public"`
+}
+
+type collectionParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+func (cfg *apiConfig) handlerCreateCollection(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	param, ok := decodeCollectionParameter(w, r)
+	if !ok {
+		return
+	}
+
+	collection, err := cfg.queries.CreateCollection(r.Context(),
+		database.CreateCollectionParams{
+			UserID:      userId,
+			Name:        param.Name,
+			Description: param.Description,
+			IsPublic:    param.IsPublic,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toCollectionResponse(collection), http.StatusCreated)
+}
+
+// handlerGetCollections lists the caller's own collections, public or not.
+func (cfg *apiConfig) handlerGetCollections(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	collections, err := cfg.queries.GetCollectionsByUserId(r.Context(),
+		database.GetCollectionsByUserIdParams{
+			UserID:     userId,
+			PublicOnly: false,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toCollectionResponses(collections), http.StatusOK)
+}
+
+// handlerGetUserCollections lists another user's public collections.
+func (cfg *apiConfig) handlerGetUserCollections(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.getOptionalViewer(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	ownerId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	if viewerID.Valid {
+		blocked, err := isBlockedEitherWay(r.Context(), cfg.queries, viewerID.UUID, ownerId)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+		if blocked {
+			writeSuccessResponse(w, []collectionResponse{}, http.StatusOK)
+			return
+		}
+	}
+
+	collections, err := cfg.queries.GetCollectionsByUserId(r.Context(),
+		database.GetCollectionsByUserIdParams{
+			UserID:     ownerId,
+			PublicOnly: !viewerID.Valid || viewerID.UUID != ownerId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toCollectionResponses(collections), http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerGetCollection(w http.ResponseWriter, r *http.Request) {
+	collection, _, ok := cfg.getViewableCollection(w, r)
+	if !ok {
+		return
+	}
+
+	writeSuccessResponse(w, toCollectionResponse(collection), http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerUpdateCollection(w http.ResponseWriter, r *http.Request) {
+	param, ok := decodeCollectionParameter(w, r)
+	if !ok {
+		return
+	}
+
+	collection, _, ok := cfg.getOwnedCollection(w, r, cfg.queries.GetCollectionById)
+	if !ok {
+		return
+	}
+
+	collection, err := cfg.queries.UpdateCollection(r.Context(),
+		database.UpdateCollectionParams{
+			ID:          collection.ID,
+			Name:        param.Name,
+			Description: param.Description,
+			IsPublic:    param.IsPublic,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toCollectionResponse(collection), http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	collection, _, ok := cfg.getOwnedCollection(w, r, cfg.queries.GetCollectionById)
+	if !ok {
+		return
+	}
+
+	if err := cfg.queries.DeleteCollection(r.Context(), collection.ID); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerGetCollectionChirps pages through a collection in its curated
+// order. The after cursor is the previous page's next_cursor.
+func (cfg *apiConfig) handlerGetCollectionChirps(w http.ResponseWriter, r *http.Request) {
+	collection, viewerID, ok := cfg.getViewableCollection(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := parseChirpPageLimit(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	after, err := parsePageCursor(r, "after")
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.GetCollectionChirpsParams{
+		CollectionID: collection.ID,
+		ViewerID:     viewerID,
+		RowLimit:     int32(limit),
+	}
+	if after != nil {
+		params.AfterPosition = sql.NullInt32{Int32: int32(after.Key), Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: after.ID, Valid: true}
+	}
+
+	rows, err := cfg.queries.GetCollectionChirps(r.Context(), params)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, database.Chirp{
+			ID:         row.ID,
+			CreatedAt:  row.CreatedAt,
+			UpdatedAt:  row.UpdatedAt,
+			Body:       row.Body,
+			UserID:     row.UserID,
+			HiddenAt:   row.HiddenAt,
+			Visibility: row.Visibility,
+		})
+	}
+
+	page := toChirpPageResponse(chirps, limit)
+	if page.NextCursor != nil {
+		last := rows[len(rows)-1]
+		cursor := pageCursor{Key: int64(last.Position), ID: last.ID}.String()
+		page.NextCursor = &cursor
+	}
+	if err := attachChirpDetails(r.Context(), cfg.queries, viewerID, page.Chirps); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, page, http.StatusOK)
+}
+
+// handlerAddCollectionChirp appends a chirp the owner can see to the end of
+// their collection. Adding a chirp that is already there is a no-op.
+func (cfg *apiConfig) handlerAddCollectionChirp(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		ChirpId uuid.UUID `json:"chirp_id"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// The lock keeps concurrent adds from computing the same position.
+	collection, userId, ok := cfg.getOwnedCollection(w, r, qtx.LockCollectionById)
+	if !ok {
+		return
+	}
+
+	_, err = getVisibleChirp(r.Context(), qtx, uuid.NullUUID{UUID: userId, Valid: true}, param.ChirpId)
+	if err != nil {
+		if errors.Is(err, errChirpNotFound) {
+			writeErrorResponse(w, err, http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	_, err = qtx.AddCollectionItem(r.Context(),
+		database.AddCollectionItemParams{
+			CollectionID: collection.ID,
+			ChirpID:      param.ChirpId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveCollectionChirp(w http.ResponseWriter, r *http.Request) {
+	collection, _, ok := cfg.getOwnedCollection(w, r, cfg.queries.GetCollectionById)
+	if !ok {
+		return
+	}
+
+	chirpIdStr := r.PathValue("chirpId")
+	chirpId, err := uuid.Parse(chirpIdStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid chirp id %q: %w", chirpIdStr, err), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := cfg.queries.RemoveCollectionItem(r.Context(),
+		database.RemoveCollectionItemParams{
+			CollectionID: collection.ID,
+			ChirpID:      chirpId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if removed == 0 {
+		writeErrorResponse(w, fmt.Errorf("chirp not in collection"), http.StatusNotFound)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerReorderCollection sets the order of a collection. chirp_ids must
+// list every chirp in the collection exactly once.
+func (cfg *apiConfig) handlerReorderCollection(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		ChirpIds []uuid.UUID `json:"chirp_ids"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	collection, _, ok := cfg.getOwnedCollection(w, r, qtx.LockCollectionById)
+	if !ok {
+		return
+	}
+
+	current, err := qtx.GetCollectionItemIds(r.Context(), collection.ID)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if !samePermutation(current, param.ChirpIds) {
+		writeErrorResponse(w, errors.New("chirp_ids must list every chirp in the collection exactly once"), http.StatusBadRequest)
+		return
+	}
+
+	_, err = qtx.ReorderCollectionItems(r.Context(),
+		database.ReorderCollectionItemsParams{
+			ChirpIds:     param.ChirpIds,
+			CollectionID: collection.ID,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// getOwnedCollection loads the collection named by the {id} path value with
+// load and checks the caller owns it. It writes the error response itself.
+func (cfg *apiConfig) getOwnedCollection(w http.ResponseWriter, r *http.Request, load func(context.Context, uuid.UUID) (database.Collection, error)) (database.Collection, uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.Collection{}, uuid.Nil, false
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.Collection{}, uuid.Nil, false
+	}
+
+	idStr := r.PathValue("id")
+	collectionId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return database.Collection{}, uuid.Nil, false
+	}
+
+	collection, err := load(r.Context(), collectionId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("collection not found"), http.StatusNotFound)
+			return database.Collection{}, uuid.Nil, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.Collection{}, uuid.Nil, false
+	}
+
+	if collection.UserID != userId {
+		if !collection.IsPublic {
+			writeErrorResponse(w, fmt.Errorf("collection not found"), http.StatusNotFound)
+			return database.Collection{}, uuid.Nil, false
+		}
+		writeErrorResponse(w, errors.New("collection does not belong to user"), http.StatusForbidden)
+		return database.Collection{}, uuid.Nil, false
+	}
+
+	return collection, userId, true
+}
+
+// getViewableCollection loads the collection named by the {id} path value
+// for an optional viewer: its owner, or anyone when it is public and the
+// owner hasn't blocked them. It writes the error response itself.
+func (cfg *apiConfig) getViewableCollection(w http.ResponseWriter, r *http.Request) (database.Collection, uuid.NullUUID, bool) {
+	viewerID, err := cfg.getOptionalViewer(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.Collection{}, uuid.NullUUID{}, false
+	}
+
+	idStr := r.PathValue("id")
+	collectionId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return database.Collection{}, uuid.NullUUID{}, false
+	}
+
+	collection, err := cfg.queries.GetCollectionById(r.Context(), collectionId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("collection not found"), http.StatusNotFound)
+			return database.Collection{}, uuid.NullUUID{}, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.Collection{}, uuid.NullUUID{}, false
+	}
+
+	if viewerID.Valid && viewerID.UUID == collection.UserID {
+		return collection, viewerID, true
+	}
+
+	if !collection.IsPublic {
+		writeErrorResponse(w, fmt.Errorf("collection not found"), http.StatusNotFound)
+		return database.Collection{}, uuid.NullUUID{}, false
+	}
+
+	if viewerID.Valid {
+		blocked, err := isBlockedEitherWay(r.Context(), cfg.queries, viewerID.UUID, collection.UserID)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return database.Collection{}, uuid.NullUUID{}, false
+		}
+		if blocked {
+			writeErrorResponse(w, fmt.Errorf("collection not found"), http.StatusNotFound)
+			return database.Collection{}, uuid.NullUUID{}, false
+		}
+	}
+
+	return collection, viewerID, true
+}
+
+func decodeCollectionParameter(w http.ResponseWriter, r *http.Request) (collectionParameter, bool) {
+	decoder := json.NewDecoder(r.Body)
+	param := collectionParameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return param, false
+	}
+
+	param.Name = strings.TrimSpace(param.Name)
+	if param.Name == "" {
+		writeErrorResponse(w, errors.New("name is required"), http.StatusBadRequest)
+		return param, false
+	}
+	if len(param.Name) > maxCollectionNameLength {
+		writeErrorResponse(w, fmt.Errorf("name must be at most %d characters", maxCollectionNameLength), http.StatusBadRequest)
+		return param, false
+	}
+	if len(param.Description) > maxCollectionDescriptionLength {
+		writeErrorResponse(w, fmt.Errorf("description must be at most %d characters", maxCollectionDescriptionLength), http.StatusBadRequest)
+		return param, false
+	}
+
+	return param, true
+}
+
+// samePermutation reports whether proposed holds exactly the ids in current,
+// each once, in any order.
+func samePermutation(current, proposed []uuid.UUID) bool {
+	if len(current) != len(proposed) {
+		return false
+	}
+
+	remaining := make(map[uuid.UUID]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+	for _, id := range proposed {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
+
+func toCollectionResponse(collection database.Collection) collectionResponse {
+	return collectionResponse{
+		Id:          collection.ID,
+		CreatedAt:   collection.CreatedAt,
+		UpdatedAt:   collection.UpdatedAt,
+		UserId:      collection.UserID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		IsPublic:    collection.IsPublic,
+	}
+}
+
+func toCollectionResponses(collections []database.Collection) []collectionResponse {
+	responses := make([]collectionResponse, 0, len(collections))
+	for _, collection := range collections {
+		responses = append(responses, toCollectionResponse(collection))
+	}
+	return responses
+}
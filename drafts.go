@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/retry"
+	"github.com/google/uuid"
+)
+
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+const draftPublishMaxAttempts = 5
+
+// draftPublishFailedMessage is shown on drafts the scheduler gave up on. The
+// underlying error stays in last_error for operators.
+const draftPublishFailedMessage = "this draft could not be published at its scheduled time; edit or reschedule it to try again"
+
+type draftResponse struct {
+	Id          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Body        string     `json:"body"`
+	UserId      uuid.UUID  `json:"user_id"`
+	Visibility  string     `json:"visibility"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+	// PublishError explains why a scheduled draft was unscheduled after
+	// failing to publish.
+	PublishError string `json:"publish_error,omitempty"`
+}
+
+type draftParameter struct {
+	Body        string     `json:"body"`
+	Visibility  string     `json:"visibility"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	param, scheduledAt, ok := decodeDraftParameter(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.queries.CreateDraft(r.Context(),
+		database.CreateDraftParams{
+			UserID:      userId,
+			Body:        param.Body,
+			ScheduledAt: scheduledAt,
+			Visibility:  param.Visibility,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toDraftResponse(draft), http.StatusCreated)
+}
+
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	drafts, err := cfg.queries.GetDraftsByUserId(r.Context(),
+		database.GetDraftsByUserIdParams{
+			UserID:        userId,
+			ScheduledOnly: r.URL.Query().Get("scheduled") == "true",
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]draftResponse, 0, len(drafts))
+	for _, draft := range drafts {
+		responses = append(responses, toDraftResponse(draft))
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := cfg.getOwnedDraft(w, r, cfg.queries.GetDraftById)
+	if !ok {
+		return
+	}
+
+	writeSuccessResponse(w, toDraftResponse(draft), http.StatusOK)
+}
+
+// handlerUpdateDraft replaces a draft's body and schedule. Sending a null
+// scheduled_at turns a scheduled chirp back into a plain draft.
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	param, scheduledAt, ok := decodeDraftParameter(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// Locking waits out a scheduler that is publishing this draft right now;
+	// once it commits the draft is gone and this reports 404.
+	draft, ok := cfg.getOwnedDraft(w, r, qtx.LockDraftById)
+	if !ok {
+		return
+	}
+
+	draft, err = qtx.UpdateDraft(r.Context(),
+		database.UpdateDraftParams{
+			ID:          draft.ID,
+			Body:        param.Body,
+			ScheduledAt: scheduledAt,
+			Visibility:  param.Visibility,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toDraftResponse(draft), http.StatusOK)
+}
+
+// handlerDeleteDraft discards a draft, which also cancels it if scheduled.
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	draft, ok := cfg.getOwnedDraft(w, r, qtx.LockDraftById)
+	if !ok {
+		return
+	}
+
+	if _, err := qtx.DeleteDraft(r.Context(), draft.ID); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerPublishDraft publishes a draft immediately, whatever its schedule.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, r *http.Request) {
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	draft, ok := cfg.getOwnedDraft(w, r, qtx.LockDraftById)
+	if !ok {
+		return
+	}
+
+	chirp, err := publishDraft(r.Context(), qtx, draft)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toChirpResponse(chirp), http.StatusCreated)
+}
+
+// runChirpScheduler publishes scheduled drafts once they are due.
+func (cfg *apiConfig) runChirpScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.publishDueDrafts(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueDrafts publishes due drafts one transaction at a time. Each
+// draft is locked with SKIP LOCKED and deleted in the same transaction that
+// creates its chirp, so it is published exactly once even when several
+// server instances run the scheduler. A draft that fails is set aside with
+// backoff so the drafts behind it still go out.
+func (cfg *apiConfig) publishDueDrafts(ctx context.Context) {
+	for {
+		claimed, err := cfg.publishNextDueDraft(ctx)
+		if err != nil {
+			log.Printf("Error publishing scheduled chirp: %s", err)
+			return
+		}
+		if !claimed {
+			return
+		}
+	}
+}
+
+// publishNextDueDraft reports whether it claimed a draft, whether or not
+// publishing it succeeded. Only errors that stop the queue, such as losing
+// the database, are returned.
+func (cfg *apiConfig) publishNextDueDraft(ctx context.Context) (bool, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	draft, err := qtx.ClaimDueDraft(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Publishing runs under a savepoint so a failure can be undone and
+	// recorded while the draft is still locked. Recording it after releasing
+	// the lock would let another scheduler publish the draft in between.
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT publish_draft"); err != nil {
+		return false, err
+	}
+	if _, publishErr := publishDraft(ctx, qtx, draft); publishErr != nil {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT publish_draft"); err != nil {
+			return false, err
+		}
+		log.Printf("Error publishing scheduled draft %s: %s", draft.ID, publishErr)
+		if err := recordDraftPublishFailure(ctx, qtx, draft, publishErr); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recordDraftPublishFailure schedules a retry with backoff or, once the
+// draft has used up its attempts, unschedules it so it shows up as a plain
+// draft carrying the error. The caller owns the transaction holding the
+// draft's lock.
+func recordDraftPublishFailure(ctx context.Context, q *database.Queries, draft database.ChirpDraft, publishErr error) error {
+	attempts := draft.Attempts + 1
+	params := database.RecordDraftPublishFailureParams{
+		ID:            draft.ID,
+		Attempts:      attempts,
+		NextAttemptAt: sql.NullTime{Time: time.Now().Add(retry.Backoff(int(attempts))), Valid: true},
+		LastError:     sql.NullString{String: publishErr.Error(), Valid: true},
+		ScheduledAt:   draft.ScheduledAt,
+	}
+	if attempts >= draftPublishMaxAttempts {
+		params.NextAttemptAt = sql.NullTime{}
+		params.ScheduledAt = sql.NullTime{}
+	}
+
+	return q.RecordDraftPublishFailure(ctx, params)
+}
+
+func publishDraft(ctx context.Context, q *database.Queries, draft database.ChirpDraft) (database.Chirp, error) {
+	chirp, err := createChirp(ctx, q, draft.UserID, draft.Body, draft.Visibility, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if _, err := q.DeleteDraft(ctx, draft.ID); err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, nil
+}
+
+// getOwnedDraft loads the draft named by the {id} path value with load and
+// checks the caller owns it. It writes the error response itself.
+func (cfg *apiConfig) getOwnedDraft(w http.ResponseWriter, r *http.Request, load func(context.Context, uuid.UUID) (database.ChirpDraft, error)) (database.ChirpDraft, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.ChirpDraft{}, false
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.ChirpDraft{}, false
+	}
+
+	idStr := r.PathValue("id")
+	draftId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return database.ChirpDraft{}, false
+	}
+
+	draft, err := load(r.Context(), draftId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("draft not found"), http.StatusNotFound)
+			return database.ChirpDraft{}, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.ChirpDraft{}, false
+	}
+
+	if draft.UserID != userId {
+		writeErrorResponse(w, fmt.Errorf("draft not found"), http.StatusNotFound)
+		return database.ChirpDraft{}, false
+	}
+
+	return draft, true
+}
+
+func decodeDraftParameter(w http.ResponseWriter, r *http.Request) (draftParameter, sql.NullTime, bool) {
+	decoder := json.NewDecoder(r.Body)
+	param := draftParameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return param, sql.NullTime{}, false
+	}
+
+	body, err := validateChirpBody(param.Body)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return param, sql.NullTime{}, false
+	}
+	param.Body = body
+
+	visibility, err := parseVisibility(param.Visibility)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return param, sql.NullTime{}, false
+	}
+	param.Visibility = visibility
+
+	if param.ScheduledAt == nil {
+		return param, sql.NullTime{}, true
+	}
+
+	if err := validateScheduledAt(*param.ScheduledAt); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return param, sql.NullTime{}, false
+	}
+
+	return param, sql.NullTime{Time: *param.ScheduledAt, Valid: true}, true
+}
+
+func validateScheduledAt(scheduledAt time.Time) error {
+	now := time.Now()
+	if !scheduledAt.After(now) {
+		return errors.New("scheduled_at must be in the future")
+	}
+	if scheduledAt.After(now.Add(maxScheduleAhead)) {
+		return errors.New("scheduled_at must be within a year")
+	}
+	return nil
+}
+
+func toDraftResponse(draft database.ChirpDraft) draftResponse {
+	res := draftResponse{
+		Id:         draft.ID,
+		CreatedAt:  draft.CreatedAt,
+		UpdatedAt:  draft.UpdatedAt,
+		Body:       draft.Body,
+		UserId:     draft.UserID,
+		Visibility: draft.Visibility,
+	}
+	if draft.ScheduledAt.Valid {
+		res.ScheduledAt = &draft.ScheduledAt.Time
+	}
+	if draft.LastError.Valid && !draft.ScheduledAt.Valid {
+		res.PublishError = draftPublishFailedMessage
+	}
+	return res
+}
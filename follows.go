@@ -0,0 +1,417 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerFollowUser follows another user. Following a private account only
+// files a follow request, answered with 202 Accepted, until they approve it.
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	followeeId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	if followeeId == userId {
+		writeErrorResponse(w, errors.New("cannot follow yourself"), http.StatusBadRequest)
+		return
+	}
+
+	followee, err := cfg.queries.GetUserById(r.Context(), followeeId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("user not found"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	blocked, err := isBlockedEitherWay(r.Context(), cfg.queries, userId, followeeId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		writeErrorResponse(w, errors.New("cannot follow this user"), http.StatusForbidden)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// An existing follow of a private account is left alone; otherwise the
+	// follow waits on the followee's approval.
+	needsApproval := false
+	if followee.IsPrivate {
+		following, err := qtx.IsFollowing(r.Context(),
+			database.IsFollowingParams{
+				FollowerID: userId,
+				FolloweeID: followeeId,
+			},
+		)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+		needsApproval = !following
+	}
+
+	var (
+		created          int64
+		notificationType string
+		status           int
+	)
+	if needsApproval {
+		created, err = qtx.CreateFollowRequest(r.Context(),
+			database.CreateFollowRequestParams{
+				RequesterID: userId,
+				TargetID:    followeeId,
+			},
+		)
+		notificationType = notificationTypeFollowRequest
+		status = http.StatusAccepted
+	} else {
+		created, err = qtx.FollowUser(r.Context(),
+			database.FollowUserParams{
+				FollowerID: userId,
+				FolloweeID: followeeId,
+			},
+		)
+		notificationType = notificationTypeFollow
+		status = http.StatusNoContent
+	}
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if created > 0 {
+		_, err = qtx.CreateNotification(r.Context(),
+			database.CreateNotificationParams{
+				UserID:  followeeId,
+				ActorID: userId,
+				Type:    notificationType,
+			},
+		)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, status)
+}
+
+// handlerUnfollowUser unfollows a user, withdrawing a pending follow request
+// as well.
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	followeeId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	_, err = qtx.UnfollowUser(r.Context(),
+		database.UnfollowUserParams{
+			FollowerID: userId,
+			FolloweeID: followeeId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	_, err = qtx.DeleteFollowRequest(r.Context(),
+		database.DeleteFollowRequestParams{
+			RequesterID: userId,
+			TargetID:    followeeId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerGetFollowRequests lists pending requests to follow the caller,
+// oldest first.
+func (cfg *apiConfig) handlerGetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		UserId    uuid.UUID `json:"user_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	requests, err := cfg.queries.GetFollowRequestsByTargetId(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]response, 0, len(requests))
+	for _, request := range requests {
+		responses = append(responses, response{
+			UserId:    request.RequesterID,
+			CreatedAt: request.CreatedAt,
+		})
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+// handlerApproveFollowRequest turns the request from the user named by the
+// {id} path value into a follow and lets them know.
+func (cfg *apiConfig) handlerApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	requesterId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	deleted, err := qtx.DeleteFollowRequest(r.Context(),
+		database.DeleteFollowRequestParams{
+			RequesterID: requesterId,
+			TargetID:    userId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		writeErrorResponse(w, fmt.Errorf("follow request not found"), http.StatusNotFound)
+		return
+	}
+
+	_, err = qtx.FollowUser(r.Context(),
+		database.FollowUserParams{
+			FollowerID: requesterId,
+			FolloweeID: userId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	_, err = qtx.CreateNotification(r.Context(),
+		database.CreateNotificationParams{
+			UserID:  requesterId,
+			ActorID: userId,
+			Type:    notificationTypeFollowApproved,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerDenyFollowRequest drops the request from the user named by the {id}
+// path value. The requester isn't notified.
+func (cfg *apiConfig) handlerDenyFollowRequest(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	requesterId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.queries.DeleteFollowRequest(r.Context(),
+		database.DeleteFollowRequestParams{
+			RequesterID: requesterId,
+			TargetID:    userId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		writeErrorResponse(w, fmt.Errorf("follow request not found"), http.StatusNotFound)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerUpdatePrivacy makes the caller's account private or public. Going
+// public approves every pending follow request.
+func (cfg *apiConfig) handlerUpdatePrivacy(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		IsPrivate bool `json:"is_private"`
+	}
+	type response struct {
+		IsPrivate bool `json:"is_private"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	user, err := qtx.UpdateUserPrivacy(r.Context(),
+		database.UpdateUserPrivacyParams{
+			ID:        userId,
+			IsPrivate: param.IsPrivate,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("user not found"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if !user.IsPrivate {
+		if _, err := qtx.ApproveAllFollowRequests(r.Context(), userId); err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, response{IsPrivate: user.IsPrivate}, http.StatusOK)
+}
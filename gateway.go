@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	gatewayWriteTimeout = 10 * time.Second
+	gatewayPongTimeout  = 60 * time.Second
+	gatewayPingInterval = 25 * time.Second
+	gatewayMaxMessage   = 4 << 10
+	gatewaySendBuffer   = 32
+	gatewayMaxTopics    = 50
+)
+
+const closeTokenExpired = 4001
+
+const (
+	gatewayTopicTimeline      = "timeline"
+	gatewayTopicThread        = "thread"
+	gatewayTopicNotifications = "notifications"
+)
+
+var gatewayUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type gatewayRequest struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+	Token string `json:"token"`
+}
+
+type gatewayMessage struct {
+	Type  string          `json:"type"`
+	Topic string          `json:"topic,omitempty"`
+	Id    int64           `json:"id,omitempty"`
+	Event string          `json:"event,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// gatewaySession is the per-connection state shared by the reader goroutine
+// and the writer loop in handlerGateway.
+type gatewaySession struct {
+	userID uuid.UUID
+
+	mu        sync.Mutex
+	topics    map[string]bool
+	expiresAt time.Time
+}
+
+func (cfg *apiConfig) handlerGateway(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	expiresAt, err := auth.GetJWTExpiry(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := loadAudienceFilter(r.Context(), cfg.queries, userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := gatewayUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Error upgrading gateway connection: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	session := &gatewaySession{
+		userID:    userId,
+		topics:    make(map[string]bool),
+		expiresAt: expiresAt,
+	}
+
+	events := cfg.chirpStream.subscribe()
+	defer cfg.chirpStream.unsubscribe(events)
+	notifications := cfg.notificationHub.subscribe(userId)
+	defer cfg.notificationHub.unsubscribe(userId, notifications)
+
+	replies := make(chan gatewayMessage, gatewaySendBuffer)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cfg.readGateway(conn, session, replies)
+	}()
+
+	ping := time.NewTicker(gatewayPingInterval)
+	defer ping.Stop()
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case reply := <-replies:
+			if reply.Type == "reauthenticated" {
+				expiry.Reset(time.Until(session.expiry()))
+			}
+			if err := writeGatewayMessage(conn, reply); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				closeGateway(conn, websocket.ClosePolicyViolation, "client too slow")
+				return
+			}
+			for _, topic := range session.matchingTopics(event) {
+				if filter.hides(event, topic == gatewayTopicTimeline) {
+					continue
+				}
+				msg := gatewayMessage{
+					Type:  "event",
+					Topic: topic,
+					Id:    event.ID,
+					Event: event.Event,
+					Data:  event.Payload,
+				}
+				if err := writeGatewayMessage(conn, msg); err != nil {
+					return
+				}
+			}
+		case notification, ok := <-notifications:
+			if !ok {
+				closeGateway(conn, websocket.ClosePolicyViolation, "client too slow")
+				return
+			}
+			if !session.subscribed(gatewayTopicNotifications) {
+				continue
+			}
+			data, err := json.Marshal(notification)
+			if err != nil {
+				log.Printf("Error encoding notification %s: %s", notification.Id, err)
+				continue
+			}
+			msg := gatewayMessage{
+				Type:  "event",
+				Topic: gatewayTopicNotifications,
+				Event: "notification.created",
+				Data:  data,
+			}
+			if err := writeGatewayMessage(conn, msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			// Pick up blocks, mutes and follows made since the connection was opened.
+			if refreshed, err := loadAudienceFilter(r.Context(), cfg.queries, userId); err == nil {
+				filter = refreshed
+			}
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(gatewayWriteTimeout)); err != nil {
+				return
+			}
+		case <-expiry.C:
+			if time.Now().Before(session.expiry()) {
+				expiry.Reset(time.Until(session.expiry()))
+				continue
+			}
+			closeGateway(conn, closeTokenExpired, "token expired")
+			return
+		}
+	}
+}
+
+// readGateway handles client requests until the connection fails. Replies
+// are handed to the writer loop; a client that floods requests faster than
+// replies can be written is disconnected rather than buffered indefinitely.
+func (cfg *apiConfig) readGateway(conn *websocket.Conn, session *gatewaySession, replies chan<- gatewayMessage) {
+	conn.SetReadLimit(gatewayMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(gatewayPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(gatewayPongTimeout))
+	})
+
+	for {
+		var req gatewayRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("Error reading gateway message: %s", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(gatewayPongTimeout))
+
+		reply := cfg.handleGatewayRequest(session, req)
+		select {
+		case replies <- reply:
+		default:
+			closeGateway(conn, websocket.ClosePolicyViolation, "too many requests")
+			return
+		}
+	}
+}
+
+func (cfg *apiConfig) handleGatewayRequest(session *gatewaySession, req gatewayRequest) gatewayMessage {
+	switch req.Type {
+	case "subscribe":
+		if err := validateGatewayTopic(req.Topic); err != nil {
+			return gatewayMessage{Type: "error", Topic: req.Topic, Error: err.Error()}
+		}
+		if err := session.subscribe(req.Topic); err != nil {
+			return gatewayMessage{Type: "error", Topic: req.Topic, Error: err.Error()}
+		}
+		return gatewayMessage{Type: "subscribed", Topic: req.Topic}
+	case "unsubscribe":
+		session.unsubscribe(req.Topic)
+		return gatewayMessage{Type: "unsubscribed", Topic: req.Topic}
+	case "ping":
+		return gatewayMessage{Type: "pong"}
+	case "reauthenticate":
+		userId, err := auth.ValidateJWT(req.Token, cfg.secret)
+		if err != nil {
+			return gatewayMessage{Type: "error", Error: err.Error()}
+		}
+		if userId != session.userID {
+			return gatewayMessage{Type: "error", Error: "token belongs to a different user"}
+		}
+		expiresAt, err := auth.GetJWTExpiry(req.Token, cfg.secret)
+		if err != nil {
+			return gatewayMessage{Type: "error", Error: err.Error()}
+		}
+		session.setExpiry(expiresAt)
+		return gatewayMessage{Type: "reauthenticated"}
+	default:
+		return gatewayMessage{Type: "error", Error: fmt.Sprintf("unknown message type %q", req.Type)}
+	}
+}
+
+// validateGatewayTopic accepts "timeline", "timeline:<author id>",
+// "thread:<chirp id>" and "notifications".
+func validateGatewayTopic(topic string) error {
+	if topic == gatewayTopicNotifications {
+		return nil
+	}
+
+	kind, id, hasID := strings.Cut(topic, ":")
+	switch kind {
+	case gatewayTopicTimeline:
+		if !hasID {
+			return nil
+		}
+	case gatewayTopicThread:
+		if !hasID {
+			return errors.New("thread topic requires a chirp id")
+		}
+	default:
+		return fmt.Errorf("unknown topic %q", topic)
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("invalid topic id %q: %w", id, err)
+	}
+	return nil
+}
+
+func (s *gatewaySession) subscribe(topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.topics[topic] && len(s.topics) >= gatewayMaxTopics {
+		return fmt.Errorf("cannot subscribe to more than %d topics", gatewayMaxTopics)
+	}
+	s.topics[topic] = true
+	return nil
+}
+
+func (s *gatewaySession) unsubscribe(topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.topics, topic)
+}
+
+func (s *gatewaySession) subscribed(topic string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.topics[topic]
+}
+
+func (s *gatewaySession) expiry() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expiresAt
+}
+
+func (s *gatewaySession) setExpiry(expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiresAt = expiresAt
+}
+
+func (s *gatewaySession) matchingTopics(event database.ChirpEvent) []string {
+	candidates := []string{
+		gatewayTopicTimeline,
+		gatewayTopicTimeline + ":" + event.UserID.String(),
+		gatewayTopicThread + ":" + event.ChirpID.String(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var topics []string
+	for _, topic := range candidates {
+		if s.topics[topic] {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+func writeGatewayMessage(conn *websocket.Conn, msg gatewayMessage) error {
+	conn.SetWriteDeadline(time.Now().Add(gatewayWriteTimeout))
+	return conn.WriteJSON(msg)
+}
+
+func closeGateway(conn *websocket.Conn, code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(gatewayWriteTimeout))
+}
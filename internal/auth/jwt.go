@@ -12,14 +12,16 @@ import (
 
 type userClaim struct {
 	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func MakeJWT(userId uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+func MakeJWT(userId uuid.UUID, role string, tokenSecret string, expiresIn time.Duration) (string, error) {
 	now := time.Now()
 	signingKey := []byte(tokenSecret)
 	claim := userClaim{
 		UserID: userId,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
@@ -56,3 +58,38 @@ func GetBearerToken(headers http.Header) (string, error) {
 	token := splitAuthHeader[1]
 	return token, nil
 }
+
+func GetJWTExpiry(tokenString, tokenSecret string) (time.Time, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &userClaim{}, func(token *jwt.Token) (any, error) {
+		return []byte(tokenSecret), nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	claims, ok := token.Claims.(*userClaim)
+	if !ok || claims.ExpiresAt == nil {
+		return time.Time{}, errors.New("token has no expiry")
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
+// GetJWTRole returns the role the token was issued with. Tokens issued
+// before roles existed carry none and are treated as RoleUser.
+func GetJWTRole(tokenString, tokenSecret string) (string, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &userClaim{}, func(token *jwt.Token) (any, error) {
+		return []byte(tokenSecret), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(*userClaim)
+	if !ok {
+		return "", errors.New("invalid token claims")
+	}
+	if claims.Role == "" {
+		return RoleUser, nil
+	}
+	return claims.Role, nil
+}
@@ -13,7 +13,7 @@ func TestMakeJWTAndValidateJWT(t *testing.T) {
 	secret := "secret"
 	duration := time.Hour
 	t.Run("JWT Match", func(t *testing.T) {
-		jwt, err := MakeJWT(uuid1, secret, duration)
+		jwt, err := MakeJWT(uuid1, RoleUser, secret, duration)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -27,7 +27,7 @@ func TestMakeJWTAndValidateJWT(t *testing.T) {
 		}
 	})
 	t.Run("JWT no Match", func(t *testing.T) {
-		jwt, err := MakeJWT(uuid1, secret, duration)
+		jwt, err := MakeJWT(uuid1, RoleUser, secret, duration)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -41,3 +41,56 @@ func TestMakeJWTAndValidateJWT(t *testing.T) {
 		}
 	})
 }
+
+func TestGetJWTExpiry(t *testing.T) {
+	secret := "secret"
+	jwt, err := MakeJWT(uuid.New(), RoleUser, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Expiry Match", func(t *testing.T) {
+		expiresAt, err := GetJWTExpiry(jwt, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if until := time.Until(expiresAt); until <= 59*time.Minute || until > time.Hour {
+			t.Errorf("expected expiry about an hour from now, got %s", expiresAt)
+		}
+	})
+	t.Run("Wrong Secret", func(t *testing.T) {
+		if _, err := GetJWTExpiry(jwt, "other"); err == nil {
+			t.Error("expected error for wrong secret")
+		}
+	})
+}
+
+func TestGetJWTRole(t *testing.T) {
+	secret := "secret"
+	tests := []struct {
+		name string
+		role string
+		want string
+	}{
+		{"Admin", RoleAdmin, RoleAdmin},
+		{"Moderator", RoleModerator, RoleModerator},
+		{"No Role", "", RoleUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwt, err := MakeJWT(uuid.New(), tt.role, secret, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			role, err := GetJWTRole(jwt, secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role != tt.want {
+				t.Errorf("expected role %q, got %q", tt.want, role)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package auth
+
+import "testing"
+
+func TestHashToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := HashToken(token)
+	if len(hash) != 64 {
+		t.Errorf("expected 64 hex characters, got %d", len(hash))
+	}
+	if hash == token {
+		t.Error("expected hash to differ from token")
+	}
+	if HashToken(token) != hash {
+		t.Error("expected hashing to be deterministic")
+	}
+	if HashToken(token+"x") == hash {
+		t.Error("expected different tokens to hash differently")
+	}
+}
@@ -0,0 +1,128 @@
+// Package chirptext normalizes chirp bodies and measures them the way users
+// see them: in grapheme clusters rather than bytes.
+package chirptext
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MaxLength is the longest a chirp may be, as counted by Length.
+	MaxLength = 140
+
+	// URLLength is what every link counts as, however long it is.
+	URLLength = 23
+
+	// maxCombiningMarks caps the marks stacked on one character, which is
+	// plenty for real scripts and emoji but stops "Zalgo" text.
+	maxCombiningMarks = 4
+)
+
+// Violation codes.
+const (
+	CodeEmpty          = "empty"
+	CodeTooLong        = "too_long"
+	CodeCombiningMarks = "too_many_combining_marks"
+)
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// Violation is one reason a body was rejected.
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Normalize converts body to NFC and strips control characters other than
+// newlines and tabs, along with invisible characters that can hide content
+// or reorder it: zero-width spaces, word joiners, byte order marks, and
+// bidirectional overrides. Zero-width joiners are kept because emoji
+// sequences depend on them.
+func Normalize(body string) string {
+	body = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || isInvisible(r) {
+			return -1
+		}
+		return r
+	}, body)
+	return norm.NFC.String(body)
+}
+
+func isInvisible(r rune) bool {
+	switch {
+	case r == '\u200b', r == '\u2060', r == '\ufeff':
+		return true
+	case r >= '\u202a' && r <= '\u202e':
+		return true
+	case r >= '\u2066' && r <= '\u2069':
+		return true
+	}
+	return false
+}
+
+// Length counts body in grapheme clusters, with each link counted as
+// URLLength.
+func Length(body string) int {
+	length := 0
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(body, -1) {
+		length += uniseg.GraphemeClusterCount(body[last:loc[0]]) + URLLength
+		last = loc[1]
+	}
+	return length + uniseg.GraphemeClusterCount(body[last:])
+}
+
+// URLs returns every http and https link in body, in order of appearance,
+// exactly as Length counts them. Trailing punctuation is left in place;
+// callers that fetch links decide how to clean them up.
+func URLs(body string) []string {
+	return urlPattern.FindAllString(body, -1)
+}
+
+// Validate reports every way a normalized body breaks the rules, or nil if
+// it is acceptable.
+func Validate(body string) []Violation {
+	var violations []Violation
+
+	if strings.TrimSpace(body) == "" {
+		violations = append(violations, Violation{
+			Code:    CodeEmpty,
+			Message: "Chirp can't be empty",
+		})
+	}
+
+	if n := Length(body); n > MaxLength {
+		violations = append(violations, Violation{
+			Code:    CodeTooLong,
+			Message: fmt.Sprintf("Chirp is too long: %d characters, the limit is %d", n, MaxLength),
+		})
+	}
+
+	gr := uniseg.NewGraphemes(body)
+	for gr.Next() {
+		marks := 0
+		for _, r := range gr.Runes() {
+			if unicode.In(r, unicode.Mn, unicode.Me) {
+				marks++
+			}
+		}
+		if marks > maxCombiningMarks {
+			violations = append(violations, Violation{
+				Code:    CodeCombiningMarks,
+				Message: fmt.Sprintf("Characters can carry at most %d combining marks", maxCombiningMarks),
+			})
+			break
+		}
+	}
+
+	return violations
+}
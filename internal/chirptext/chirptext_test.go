@@ -0,0 +1,112 @@
+package chirptext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Plain", "hello world", "hello world"},
+		{"NFC", "cafe\u0301", "caf\u00e9"},
+		{"Keeps Newlines And Tabs", "a\nb\tc", "a\nb\tc"},
+		{"Strips Controls", "a\x00b\x1bc\u0085", "abc"},
+		{"Strips Zero Width", "a\u200bb\u2060c\ufeff", "abc"},
+		{"Strips Bidi Overrides", "\u202eevil\u202c \u2066x\u2069", "evil x"},
+		{"Keeps ZWJ Emoji", "\U0001F468\u200d\U0001F469\u200d\U0001F467", "\U0001F468\u200d\U0001F469\u200d\U0001F467"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Normalize(tc.input); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"Empty", "", 0},
+		{"ASCII", "hello", 5},
+		{"Emoji", strings.Repeat("\U0001F600", 50), 50},
+		{"Family Emoji", "\U0001F468\u200d\U0001F469\u200d\U0001F467", 1},
+		{"Flag", "\U0001F1F0\U0001F1F7", 1},
+		{"Combining", "e\u0301", 1},
+		{"Hangul Jamo", "\u1100\u1161\u11a8", 1},
+		{"URL", "see https://example.com/a/very/long/path/that/goes/on/and/on", 4 + URLLength},
+		{"Two URLs", "http://a.example http://b.example", 2*URLLength + 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Length(tc.input); got != tc.want {
+				t.Errorf("expected %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"None", "no links here", nil},
+		{"One", "see https://example.com/a?b=c", []string{"https://example.com/a?b=c"}},
+		{"Several", "http://a.example and https://b.example", []string{"http://a.example", "https://b.example"}},
+		{"Stops At Quote", `<a href="https://example.com">`, []string{"https://example.com"}},
+		{"Keeps Punctuation", "(https://example.com).", []string{"https://example.com)."}},
+		{"Other Schemes", "ftp://example.com mailto:a@example.com", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := URLs(tc.input); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"OK", "hello world", nil},
+		{"Max Length", strings.Repeat("a", MaxLength), nil},
+		{"Max Length Emoji", strings.Repeat("\U0001F600", MaxLength), nil},
+		{"Empty", "", []string{CodeEmpty}},
+		{"Whitespace Only", " \n\t ", []string{CodeEmpty}},
+		{"Too Long", strings.Repeat("a", MaxLength+1), []string{CodeTooLong}},
+		{"Long URL Fits", "https://example.com/" + strings.Repeat("x", 200), nil},
+		{"Keycap Emoji", "1\ufe0f\u20e3", nil},
+		{"Zalgo", "z" + strings.Repeat("\u0336", 20), []string{CodeCombiningMarks}},
+		{"Too Long And Zalgo", strings.Repeat("a", MaxLength) + "z" + strings.Repeat("\u0336", 20), []string{CodeTooLong, CodeCombiningMarks}},
+		{"Whitespace Too Long", strings.Repeat(" ", MaxLength+1), []string{CodeEmpty, CodeTooLong}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, v := range Validate(tc.input) {
+				got = append(got, v.Code)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -7,23 +7,25 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3)
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, visibility
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	Visibility string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.Visibility)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +33,8 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.HiddenAt,
+		&i.Visibility,
 	)
 	return i, err
 }
@@ -45,8 +49,47 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getAllChirpsByUserId = `-- name: GetAllChirpsByUserId :many
+SELECT id, created_at, updated_at, body, user_id, hidden_at, visibility
+FROM chirps
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+// Includes chirps hidden by moderators; used for data exports.
+func (q *Queries) GetAllChirpsByUserId(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsByUserId, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, hidden_at, visibility
 FROM chirps
 WHERE id = $1
 `
@@ -60,18 +103,76 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.HiddenAt,
+		&i.Visibility,
 	)
 	return i, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, hidden_at, visibility
 FROM chirps
-ORDER BY created_at ASC
+WHERE hidden_at IS NULL
+    AND (
+        $1::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = $1 AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = $1)
+        )
+    )
+    AND (
+        $1::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM mutes m
+            WHERE m.muter_id = $1
+                AND m.muted_id = chirps.user_id
+        )
+    )
+    AND (
+        chirps.user_id = $1
+        OR (
+            chirps.visibility = 'public'
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = $1
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        $2::timestamptz IS NULL
+        OR (created_at, id) > ($2::timestamptz, $3::uuid)
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT $4::int
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type GetChirpsParams struct {
+	ViewerID  uuid.NullUUID
+	AfterTime sql.NullTime
+	AfterID   uuid.NullUUID
+	RowLimit  sql.NullInt32
+}
+
+// Keyset-paginated on (created_at, id).
+// Hides authors the viewer has blocked, been blocked by or muted, and
+// chirps the viewer isn't in the audience of. Unlisted chirps stay off the
+// timeline, and private accounts' chirps are only shown to their followers.
+func (q *Queries) GetChirps(ctx context.Context, arg GetChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, arg.ViewerID, arg.AfterTime, arg.AfterID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +186,8 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -100,14 +203,62 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByAuthorId = `-- name: GetChirpsByAuthorId :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, hidden_at, visibility
 FROM chirps
 WHERE user_id = $1
-ORDER BY created_at ASC
+    AND hidden_at IS NULL
+    AND (
+        $2::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = $2 AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = $2)
+        )
+    )
+    AND (
+        chirps.user_id = $2
+        OR (
+            chirps.visibility IN ('public', 'unlisted')
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'unlisted', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = $2
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        $3::timestamptz IS NULL
+        OR (created_at, id) > ($3::timestamptz, $4::uuid)
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT $5::int
 `
 
-func (q *Queries) GetChirpsByAuthorId(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorId, userID)
+type GetChirpsByAuthorIdParams struct {
+	UserID    uuid.UUID
+	ViewerID  uuid.NullUUID
+	AfterTime sql.NullTime
+	AfterID   uuid.NullUUID
+	RowLimit  sql.NullInt32
+}
+
+// Keyset-paginated on (created_at, id).
+// Mutes only apply to the timeline, so an author's own page ignores them.
+// Unlisted chirps appear here; followers-only and private ones only to
+// their audience. A private account's chirps are all followers-only.
+func (q *Queries) GetChirpsByAuthorId(ctx context.Context, arg GetChirpsByAuthorIdParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorId, arg.UserID, arg.ViewerID, arg.AfterTime, arg.AfterID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +272,8 @@ func (q *Queries) GetChirpsByAuthorId(ctx context.Context, userID uuid.UUID) ([]
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -136,14 +289,62 @@ func (q *Queries) GetChirpsByAuthorId(ctx context.Context, userID uuid.UUID) ([]
 }
 
 const getChirpsByAuthorIdDesc = `-- name: GetChirpsByAuthorIdDesc :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, hidden_at, visibility
 FROM chirps
 WHERE user_id = $1
-ORDER BY created_at DESC
+    AND hidden_at IS NULL
+    AND (
+        $2::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = $2 AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = $2)
+        )
+    )
+    AND (
+        chirps.user_id = $2
+        OR (
+            chirps.visibility IN ('public', 'unlisted')
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'unlisted', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = $2
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        $3::timestamptz IS NULL
+        OR (created_at, id) < ($3::timestamptz, $4::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $5::int
 `
 
-func (q *Queries) GetChirpsByAuthorIdDesc(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorIdDesc, userID)
+type GetChirpsByAuthorIdDescParams struct {
+	UserID     uuid.UUID
+	ViewerID   uuid.NullUUID
+	BeforeTime sql.NullTime
+	BeforeID   uuid.NullUUID
+	RowLimit   sql.NullInt32
+}
+
+// Keyset-paginated on (created_at, id).
+// Mutes only apply to the timeline, so an author's own page ignores them.
+// Unlisted chirps appear here; followers-only and private ones only to
+// their audience. A private account's chirps are all followers-only.
+func (q *Queries) GetChirpsByAuthorIdDesc(ctx context.Context, arg GetChirpsByAuthorIdDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorIdDesc, arg.UserID, arg.ViewerID, arg.BeforeTime, arg.BeforeID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +358,8 @@ func (q *Queries) GetChirpsByAuthorIdDesc(ctx context.Context, userID uuid.UUID)
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -172,13 +375,69 @@ func (q *Queries) GetChirpsByAuthorIdDesc(ctx context.Context, userID uuid.UUID)
 }
 
 const getChirpsDesc = `-- name: GetChirpsDesc :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, hidden_at, visibility
 FROM chirps
-ORDER BY created_at DESC
+WHERE hidden_at IS NULL
+    AND (
+        $1::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = $1 AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = $1)
+        )
+    )
+    AND (
+        $1::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM mutes m
+            WHERE m.muter_id = $1
+                AND m.muted_id = chirps.user_id
+        )
+    )
+    AND (
+        chirps.user_id = $1
+        OR (
+            chirps.visibility = 'public'
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = $1
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        $2::timestamptz IS NULL
+        OR (created_at, id) < ($2::timestamptz, $3::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $4::int
 `
 
-func (q *Queries) GetChirpsDesc(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsDesc)
+type GetChirpsDescParams struct {
+	ViewerID   uuid.NullUUID
+	BeforeTime sql.NullTime
+	BeforeID   uuid.NullUUID
+	RowLimit   sql.NullInt32
+}
+
+// Keyset-paginated on (created_at, id).
+// Hides authors the viewer has blocked, been blocked by or muted, and
+// chirps the viewer isn't in the audience of. Unlisted chirps stay off the
+// timeline, and private accounts' chirps are only shown to their followers.
+func (q *Queries) GetChirpsDesc(ctx context.Context, arg GetChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsDesc, arg.ViewerID, arg.BeforeTime, arg.BeforeID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -192,6 +451,8 @@ func (q *Queries) GetChirpsDesc(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -205,3 +466,25 @@ func (q *Queries) GetChirpsDesc(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const hideChirp = `-- name: HideChirp :one
+UPDATE chirps
+SET hidden_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, visibility
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, hideChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.HiddenAt,
+		&i.Visibility,
+	)
+	return i, err
+}
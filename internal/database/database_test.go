@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// testQueries returns queries running in a transaction that is rolled back
+// when the test ends. TEST_DATABASE_URL must point at a database migrated
+// with goose; without it the test is skipped.
+func testQueries(t *testing.T) *Queries {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("starting transaction: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	return New(db).WithTx(tx)
+}
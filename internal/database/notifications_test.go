@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUnreadNotificationsHideBlockedActors(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+
+	newUser := func(email string) User {
+		t.Helper()
+		user, err := q.CreateUser(ctx, CreateUserParams{Email: email, HashedPassword: "unused"})
+		if err != nil {
+			t.Fatalf("creating %s: %v", email, err)
+		}
+		return user
+	}
+	recipient := newUser("recipient-" + uuid.NewString() + "@example.com")
+	blocked := newUser("blocked-" + uuid.NewString() + "@example.com")
+	other := newUser("other-" + uuid.NewString() + "@example.com")
+
+	for _, actor := range []User{blocked, other} {
+		_, err := q.CreateNotification(ctx, CreateNotificationParams{UserID: recipient.ID, ActorID: actor.ID, Type: "follow"})
+		if err != nil {
+			t.Fatalf("creating notification: %v", err)
+		}
+	}
+
+	countUnread := func() int64 {
+		t.Helper()
+		count, err := q.CountUnreadNotifications(ctx, recipient.ID)
+		if err != nil {
+			t.Fatalf("counting unread notifications: %v", err)
+		}
+		return count
+	}
+
+	if got := countUnread(); got != 2 {
+		t.Fatalf("expected 2 unread before blocking, got %d", got)
+	}
+
+	if _, err := q.BlockUser(ctx, BlockUserParams{BlockerID: recipient.ID, BlockedID: blocked.ID}); err != nil {
+		t.Fatalf("blocking: %v", err)
+	}
+	if got := countUnread(); got != 1 {
+		t.Fatalf("expected 1 unread after blocking, got %d", got)
+	}
+
+	marked, err := q.MarkAllNotificationsRead(ctx, recipient.ID)
+	if err != nil {
+		t.Fatalf("marking all read: %v", err)
+	}
+	if marked != 1 {
+		t.Errorf("expected to mark 1 notification read, marked %d", marked)
+	}
+	if got := countUnread(); got != 0 {
+		t.Errorf("expected 0 unread after marking all read, got %d", got)
+	}
+}
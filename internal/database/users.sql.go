@@ -7,14 +7,81 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const banUser = `-- name: BanUser :one
+UPDATE users
+SET banned_at = NOW(),
+    restriction_reason = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type BanUserParams struct {
+	ID                uuid.UUID
+	RestrictionReason string
+}
+
+func (q *Queries) BanUser(ctx context.Context, arg BanUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, banUser, arg.ID, arg.RestrictionReason)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const cancelUserDeletion = `-- name: CancelUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, cancelUserDeletion, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
 `
 
 type CreateUserParams struct {
@@ -32,6 +99,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
 	)
 	return i, err
 }
@@ -45,8 +120,51 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUsersDueForDeletion = `-- name: DeleteUsersDueForDeletion :execrows
+DELETE FROM users
+WHERE deletion_scheduled_for <= NOW()
+`
+
+func (q *Queries) DeleteUsersDueForDeletion(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUsersDueForDeletion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const downgradeFromChirpyRed = `-- name: DowngradeFromChirpyRed :one
+UPDATE users
+SET is_chirpy_red = false,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+func (q *Queries) DowngradeFromChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, downgradeFromChirpyRed, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
 FROM users
 WHERE email = $1
 `
@@ -61,27 +179,432 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+FROM users
+WHERE handle = $1
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
 	)
 	return i, err
 }
 
-const updateUserCredential = `-- name: UpdateUserCredential :one
+const getUserById = `-- name: GetUserById :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const lockUserById = `-- name: LockUserById :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, lockUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const reinstateUser = `-- name: ReinstateUser :one
+UPDATE users
+SET suspended_until = NULL,
+    banned_at = NULL,
+    restriction_reason = '',
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+func (q *Queries) ReinstateUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, reinstateUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type ScheduleUserDeletionParams struct {
+	ID                   uuid.UUID
+	DeletionScheduledFor sql.NullTime
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledFor)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users
+SET suspended_until = $2,
+    restriction_reason = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type SuspendUserParams struct {
+	ID                uuid.UUID
+	SuspendedUntil    sql.NullTime
+	RestrictionReason string
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil, arg.RestrictionReason)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const updateUserDmPolicy = `-- name: UpdateUserDmPolicy :one
+UPDATE users
+SET dm_policy = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type UpdateUserDmPolicyParams struct {
+	ID       uuid.UUID
+	DmPolicy string
+}
+
+func (q *Queries) UpdateUserDmPolicy(ctx context.Context, arg UpdateUserDmPolicyParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserDmPolicy, arg.ID, arg.DmPolicy)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
 UPDATE users
 SET email = $2,
-    hashed_password = $3,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type UpdateUserEmailParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const updateUserHandle = `-- name: UpdateUserHandle :one
+UPDATE users
+SET handle = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
 `
 
-type UpdateUserCredentialParams struct {
+type UpdateUserHandleParams struct {
+	ID     uuid.UUID
+	Handle sql.NullString
+}
+
+func (q *Queries) UpdateUserHandle(ctx context.Context, arg UpdateUserHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserHandle, arg.ID, arg.Handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type UpdateUserPasswordParams struct {
 	ID             uuid.UUID
-	Email          string
 	HashedPassword string
 }
 
-func (q *Queries) UpdateUserCredential(ctx context.Context, arg UpdateUserCredentialParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserCredential, arg.ID, arg.Email, arg.HashedPassword)
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const updateUserPrivacy = `-- name: UpdateUserPrivacy :one
+UPDATE users
+SET is_private = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type UpdateUserPrivacyParams struct {
+	ID        uuid.UUID
+	IsPrivate bool
+}
+
+func (q *Queries) UpdateUserPrivacy(ctx context.Context, arg UpdateUserPrivacyParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPrivacy, arg.ID, arg.IsPrivate)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type UpdateUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
+	)
+	return i, err
+}
+
+const updateUserRoleByEmail = `-- name: UpdateUserRoleByEmail :one
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE email = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
+`
+
+type UpdateUserRoleByEmailParams struct {
+	Email string
+	Role  string
+}
+
+func (q *Queries) UpdateUserRoleByEmail(ctx context.Context, arg UpdateUserRoleByEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRoleByEmail, arg.Email, arg.Role)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -90,6 +613,14 @@ func (q *Queries) UpdateUserCredential(ctx context.Context, arg UpdateUserCreden
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
 	)
 	return i, err
 }
@@ -99,7 +630,7 @@ UPDATE users
 SET is_chirpy_red = true,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_policy, suspended_until, role, banned_at, restriction_reason, deletion_scheduled_for, is_private, handle
 `
 
 func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -112,6 +643,14 @@ func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.DmPolicy,
+		&i.SuspendedUntil,
+		&i.Role,
+		&i.BannedAt,
+		&i.RestrictionReason,
+		&i.DeletionScheduledFor,
+		&i.IsPrivate,
+		&i.Handle,
 	)
 	return i, err
 }
@@ -0,0 +1,72 @@
+// Package dataexport writes the zip archive of JSON files handed to users
+// who ask for a copy of their data.
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// File is one JSON document in the archive.
+type File struct {
+	Name string
+	Data any
+}
+
+// Chirp is an exported chirp. Visibility is included so that a
+// followers-only or private chirp can be told apart from a public one.
+type Chirp struct {
+	Id         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	Visibility string     `json:"visibility"`
+	HiddenAt   *time.Time `json:"hidden_at,omitempty"`
+}
+
+// Chirps converts the user's chirps for export.
+func Chirps(chirps []database.Chirp) []Chirp {
+	exports := make([]Chirp, 0, len(chirps))
+	for _, c := range chirps {
+		export := Chirp{
+			Id:         c.ID,
+			CreatedAt:  c.CreatedAt,
+			UpdatedAt:  c.UpdatedAt,
+			Body:       c.Body,
+			Visibility: c.Visibility,
+		}
+		if c.HiddenAt.Valid {
+			export.HiddenAt = &c.HiddenAt.Time
+		}
+		exports = append(exports, export)
+	}
+	return exports
+}
+
+// Archive writes files as indented JSON into a zip archive.
+func Archive(files []File) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		f, err := archive.Create(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.Data); err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", file.Name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestArchiveChirps(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	chirps := []database.Chirp{
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Body: "hello", Visibility: "public"},
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Body: "friends only", Visibility: "followers"},
+		{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Body: "hidden", Visibility: "private", HiddenAt: sql.NullTime{Time: now, Valid: true}},
+	}
+
+	data, err := Archive([]File{{Name: "chirps.json", Data: Chirps(chirps)}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != "chirps.json" {
+		t.Fatalf("expected only chirps.json, got %v", reader.File)
+	}
+	f, err := reader.File[0].Open()
+	if err != nil {
+		t.Fatalf("opening chirps.json: %v", err)
+	}
+	defer f.Close()
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("reading chirps.json: %v", err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("decoding chirps.json: %v", err)
+	}
+	if len(got) != len(chirps) {
+		t.Fatalf("expected %d chirps, got %d", len(chirps), len(got))
+	}
+	for i, c := range chirps {
+		if got[i]["id"] != c.ID.String() {
+			t.Errorf("chirp %d: expected id %s, got %v", i, c.ID, got[i]["id"])
+		}
+		if got[i]["visibility"] != c.Visibility {
+			t.Errorf("chirp %d: expected visibility %q, got %v", i, c.Visibility, got[i]["visibility"])
+		}
+		if _, ok := got[i]["hidden_at"]; ok != c.HiddenAt.Valid {
+			t.Errorf("chirp %d: expected hidden_at present %v, got %v", i, c.HiddenAt.Valid, got[i]["hidden_at"])
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package dberr classifies errors from database/sql and lib/pq so handlers
+// answer the same failure with the same HTTP status.
+package dberr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Kind is a class of database failure.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	NotFound
+	UniqueViolation
+	ForeignKeyViolation
+	CheckViolation
+	SerializationFailure
+	Connection
+	Canceled
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NotFound:
+		return "not_found"
+	case UniqueViolation:
+		return "unique_violation"
+	case ForeignKeyViolation:
+		return "foreign_key_violation"
+	case CheckViolation:
+		return "check_violation"
+	case SerializationFailure:
+		return "serialization_failure"
+	case Connection:
+		return "connection"
+	case Canceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// Status is the HTTP status a handler should answer with:
+//
+//   - NotFound: 404
+//   - UniqueViolation and ForeignKeyViolation: 409, the request conflicts
+//     with existing data
+//   - CheckViolation: 400, the request carried data the schema rejects
+//   - SerializationFailure: 409, a concurrent request won and a retry may
+//     succeed
+//   - Connection and Canceled: 503, the database is unavailable
+//   - Unknown: 500
+func (k Kind) Status() int {
+	switch k {
+	case NotFound:
+		return http.StatusNotFound
+	case UniqueViolation, ForeignKeyViolation, SerializationFailure:
+		return http.StatusConflict
+	case CheckViolation:
+		return http.StatusBadRequest
+	case Connection, Canceled:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Classify reports what kind of database failure err is. Wrapped errors
+// are unwrapped.
+func Classify(err error) Kind {
+	if err == nil {
+		return Unknown
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return classifyCode(string(pqErr.Code))
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return Canceled
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return Connection
+	}
+	return Unknown
+}
+
+// classifyCode maps a Postgres SQLSTATE code.
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html.
+func classifyCode(code string) Kind {
+	switch code {
+	case "23505":
+		return UniqueViolation
+	case "23503":
+		return ForeignKeyViolation
+	case "23502", "23514":
+		return CheckViolation
+	case "40001", "40P01":
+		return SerializationFailure
+	case "57014":
+		return Canceled
+	case "57P01", "57P02", "57P03", "53300":
+		return Connection
+	}
+	if strings.HasPrefix(code, "08") {
+		return Connection
+	}
+	return Unknown
+}
+
+// Status is shorthand for Classify(err).Status().
+func Status(err error) int {
+	return Classify(err).Status()
+}
+
+// Constraint returns the name of the constraint err violated, if any.
+func Constraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package dberr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantKind   Kind
+		wantStatus int
+	}{
+		{"Nil", nil, Unknown, http.StatusInternalServerError},
+		{"Plain", errors.New("boom"), Unknown, http.StatusInternalServerError},
+		{"No Rows", sql.ErrNoRows, NotFound, http.StatusNotFound},
+		{"Wrapped No Rows", fmt.Errorf("loading user: %w", sql.ErrNoRows), NotFound, http.StatusNotFound},
+		{"Unique", &pq.Error{Code: "23505"}, UniqueViolation, http.StatusConflict},
+		{"Wrapped Unique", fmt.Errorf("creating user: %w", &pq.Error{Code: "23505"}), UniqueViolation, http.StatusConflict},
+		{"Foreign Key", &pq.Error{Code: "23503"}, ForeignKeyViolation, http.StatusConflict},
+		{"Not Null", &pq.Error{Code: "23502"}, CheckViolation, http.StatusBadRequest},
+		{"Check", &pq.Error{Code: "23514"}, CheckViolation, http.StatusBadRequest},
+		{"Serialization", &pq.Error{Code: "40001"}, SerializationFailure, http.StatusConflict},
+		{"Deadlock", &pq.Error{Code: "40P01"}, SerializationFailure, http.StatusConflict},
+		{"Query Canceled", &pq.Error{Code: "57014"}, Canceled, http.StatusServiceUnavailable},
+		{"Admin Shutdown", &pq.Error{Code: "57P01"}, Connection, http.StatusServiceUnavailable},
+		{"Too Many Connections", &pq.Error{Code: "53300"}, Connection, http.StatusServiceUnavailable},
+		{"Connection Failure", &pq.Error{Code: "08006"}, Connection, http.StatusServiceUnavailable},
+		{"Syntax Error", &pq.Error{Code: "42601"}, Unknown, http.StatusInternalServerError},
+		{"Bad Conn", driver.ErrBadConn, Connection, http.StatusServiceUnavailable},
+		{"Conn Done", sql.ErrConnDone, Connection, http.StatusServiceUnavailable},
+		{"Net Error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, Connection, http.StatusServiceUnavailable},
+		{"Context Canceled", context.Canceled, Canceled, http.StatusServiceUnavailable},
+		{"Deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), Canceled, http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Classify(tc.err); got != tc.wantKind {
+				t.Errorf("expected kind %s, got %s", tc.wantKind, got)
+			}
+			if got := Status(tc.err); got != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, got)
+			}
+		})
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"Plain", errors.New("boom"), ""},
+		{"Unique", &pq.Error{Code: "23505", Constraint: "users_email_key"}, "users_email_key"},
+		{"Wrapped", fmt.Errorf("x: %w", &pq.Error{Code: "23503", Constraint: "chirps_user_id_fkey"}), "chirps_user_id_fkey"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Constraint(tc.err); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Package jsonerr picks apart encoding/json errors that have no type of
+// their own.
+package jsonerr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// unknownFieldPrefix starts the message Decoder.Decode returns for an object
+// key with no matching field when DisallowUnknownFields is set. The package
+// has no error type for it, so the message is all there is to go on; the
+// tests pin it so a change in encoding/json fails loudly.
+const unknownFieldPrefix = "json: unknown field "
+
+// UnknownField reports the key named in an unknown-field error from a
+// Decoder with DisallowUnknownFields set.
+func UnknownField(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	quoted, ok := strings.CutPrefix(err.Error(), unknownFieldPrefix)
+	if !ok {
+		return "", false
+	}
+	if field, err := strconv.Unquote(quoted); err == nil {
+		return field, true
+	}
+	return strings.Trim(quoted, `"`), true
+}
@@ -0,0 +1,52 @@
+package jsonerr
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnknownField(t *testing.T) {
+	type target struct {
+		Email string `json:"email"`
+		Inner struct {
+			Name string `json:"name"`
+		} `json:"inner"`
+	}
+
+	cases := []struct {
+		name      string
+		body      string
+		wantField string
+		wantOK    bool
+	}{
+		{"Known Fields", `{"email": "a@example.com", "inner": {"name": "a"}}`, "", false},
+		{"Unknown Field", `{"email": "a@example.com", "admin": true}`, "admin", true},
+		{"Nested Unknown Field", `{"inner": {"role": "admin"}}`, "role", true},
+		{"Quote In Name", `{"a\"b": 1}`, `a"b`, true},
+		{"Syntax Error", `{"email": }`, "", false},
+		{"Type Error", `{"email": 1}`, "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(tc.body))
+			decoder.DisallowUnknownFields()
+			err := decoder.Decode(&target{})
+
+			field, ok := UnknownField(err)
+			if ok != tc.wantOK || field != tc.wantField {
+				t.Errorf("expected (%q, %v), got (%q, %v) from %v", tc.wantField, tc.wantOK, field, ok, err)
+			}
+		})
+	}
+}
+
+func TestUnknownFieldOtherErrors(t *testing.T) {
+	for _, err := range []error{nil, errors.New("unknown field email")} {
+		if field, ok := UnknownField(err); ok {
+			t.Errorf("expected no field from %v, got %q", err, field)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := Message{
+		To:      "alice@example.com",
+		Subject: "Confirm your email",
+		Body:    "Hello\nWorld",
+	}
+
+	data, err := buildMessage("chirpy@example.com", msg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "From: chirpy@example.com\r\n" +
+		"To: alice@example.com\r\n" +
+		"Subject: Confirm your email\r\n" +
+		"Date: Thu, 02 Jan 2025 03:04:05 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		"Hello\r\nWorld"
+	if string(data) != want {
+		t.Errorf("unexpected message:\n%q\nwant:\n%q", data, want)
+	}
+}
+
+func TestBuildMessageHeaderInjection(t *testing.T) {
+	cases := []struct {
+		name string
+		from string
+		msg  Message
+	}{
+		{"To", "chirpy@example.com", Message{To: "alice@example.com\r\nBcc: eve@example.com", Subject: "Hi"}},
+		{"Subject", "chirpy@example.com", Message{To: "alice@example.com", Subject: "Hi\nBcc: eve@example.com"}},
+		{"From", "chirpy@example.com\n", Message{To: "alice@example.com", Subject: "Hi"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := buildMessage(tc.from, tc.msg, time.Now())
+			if !errors.Is(err, ErrHeaderInjection) {
+				t.Errorf("expected ErrHeaderInjection, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildMessageBodyLineBreaks(t *testing.T) {
+	data, err := buildMessage("chirpy@example.com", Message{To: "a@example.com", Subject: "s", Body: "one\ntwo\nthree"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), "\r\n\r\none\r\ntwo\r\nthree") {
+		t.Errorf("expected CRLF line endings in body, got %q", data)
+	}
+}
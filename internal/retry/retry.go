@@ -0,0 +1,29 @@
+// Package retry holds the backoff schedule shared by the background jobs
+// that retry failed work: webhook deliveries, scheduled drafts and link
+// previews.
+package retry
+
+import "time"
+
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Backoff returns how long to wait before retrying work that has already
+// failed attempts times: 30s, 1m, 2m, ... capped at six hours.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return baseBackoff
+	}
+
+	backoff := baseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return backoff
+}
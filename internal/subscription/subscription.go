@@ -0,0 +1,107 @@
+// Package subscription holds the Chirpy Red subscription state machine that
+// Polka billing events drive.
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Billing events, as Polka names them.
+const (
+	EventUpgraded      = "user.upgraded"
+	EventDowngraded    = "user.downgraded"
+	EventRenewed       = "user.renewed"
+	EventPaymentFailed = "user.payment_failed"
+	EventGracePeriod   = "user.grace_period"
+)
+
+const (
+	StatusActive      = "active"
+	StatusPastDue     = "past_due"
+	StatusGracePeriod = "grace_period"
+	StatusCanceled    = "canceled"
+	// StatusExpired is set by the expiry job (ExpireLapsedSubscriptions)
+	// once a period or grace period ends without a renewal.
+	StatusExpired = "expired"
+)
+
+const (
+	DefaultPeriod      = 30 * 24 * time.Hour
+	DefaultGracePeriod = 7 * 24 * time.Hour
+)
+
+// ErrNotApplicable is returned for events that don't apply to the current
+// state, such as a payment failure for a user who never subscribed or one
+// arriving after the subscription was canceled or expired. They are ignored rather than
+// allowed to grant or extend Chirpy Red.
+var ErrNotApplicable = errors.New("subscription: event does not apply to the current state")
+
+// State is a user's subscription. GraceUntil is zero outside a grace period.
+type State struct {
+	Status     string
+	PeriodEnd  time.Time
+	GraceUntil time.Time
+}
+
+// Event is a billing event. PeriodEnd and GraceUntil are optional and fall
+// back to DefaultPeriod and DefaultGracePeriod from now.
+type Event struct {
+	Name       string
+	PeriodEnd  *time.Time
+	GraceUntil *time.Time
+}
+
+// Next returns the state event moves current to. current is nil for users
+// without a subscription, who, like canceled and expired subscriptions, can
+// only be upgraded or renewed.
+func Next(current *State, event Event, now time.Time) (State, error) {
+	switch event.Name {
+	case EventUpgraded, EventRenewed:
+		next := State{Status: StatusActive, PeriodEnd: now.Add(DefaultPeriod)}
+		if event.PeriodEnd != nil {
+			next.PeriodEnd = *event.PeriodEnd
+		}
+		return next, nil
+	case EventPaymentFailed, EventGracePeriod, EventDowngraded:
+	default:
+		return State{}, fmt.Errorf("subscription: unsupported event %q", event.Name)
+	}
+
+	if current == nil || current.Status == StatusCanceled || current.Status == StatusExpired {
+		return State{}, ErrNotApplicable
+	}
+	next := *current
+
+	switch event.Name {
+	case EventPaymentFailed:
+		// A late failure notice mustn't cut short a grace period that was
+		// already granted for the same missed payment.
+		if next.Status == StatusGracePeriod {
+			return State{}, ErrNotApplicable
+		}
+		next.Status = StatusPastDue
+	case EventGracePeriod:
+		next.Status = StatusGracePeriod
+		next.GraceUntil = now.Add(DefaultGracePeriod)
+		if event.GraceUntil != nil {
+			next.GraceUntil = *event.GraceUntil
+		}
+	case EventDowngraded:
+		next = State{Status: StatusCanceled, PeriodEnd: now}
+	}
+	return next, nil
+}
+
+// GrantsChirpyRed reports whether a subscription in the given status keeps
+// its Chirpy Red perks. Past-due and grace-period subscriptions stay
+// upgraded until the expiry job sees their deadline pass.
+func GrantsChirpyRed(status string) bool {
+	switch status {
+	case StatusActive, StatusPastDue, StatusGracePeriod:
+		return true
+	default:
+		return false
+	}
+}
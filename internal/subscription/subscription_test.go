@@ -0,0 +1,183 @@
+package subscription
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	periodEnd := now.Add(10 * 24 * time.Hour)
+	graceUntil := now.Add(3 * 24 * time.Hour)
+
+	active := &State{Status: StatusActive, PeriodEnd: periodEnd}
+	pastDue := &State{Status: StatusPastDue, PeriodEnd: periodEnd}
+	grace := &State{Status: StatusGracePeriod, PeriodEnd: periodEnd, GraceUntil: graceUntil}
+	canceled := &State{Status: StatusCanceled, PeriodEnd: now.Add(-time.Hour)}
+	expired := &State{Status: StatusExpired, PeriodEnd: now.Add(-time.Hour)}
+
+	cases := []struct {
+		name    string
+		current *State
+		event   Event
+		want    State
+		wantErr error
+	}{
+		{
+			name:  "Upgrade Without Subscription",
+			event: Event{Name: EventUpgraded},
+			want:  State{Status: StatusActive, PeriodEnd: now.Add(DefaultPeriod)},
+		},
+		{
+			name:  "Upgrade With Period End",
+			event: Event{Name: EventUpgraded, PeriodEnd: &periodEnd},
+			want:  State{Status: StatusActive, PeriodEnd: periodEnd},
+		},
+		{
+			name:  "Renew Without Subscription",
+			event: Event{Name: EventRenewed},
+			want:  State{Status: StatusActive, PeriodEnd: now.Add(DefaultPeriod)},
+		},
+		{
+			name:    "Renew Clears Grace",
+			current: grace,
+			event:   Event{Name: EventRenewed, PeriodEnd: &periodEnd},
+			want:    State{Status: StatusActive, PeriodEnd: periodEnd},
+		},
+		{
+			name:    "Renew Canceled",
+			current: canceled,
+			event:   Event{Name: EventRenewed},
+			want:    State{Status: StatusActive, PeriodEnd: now.Add(DefaultPeriod)},
+		},
+		{
+			name:    "Renew Expired",
+			current: expired,
+			event:   Event{Name: EventRenewed, PeriodEnd: &periodEnd},
+			want:    State{Status: StatusActive, PeriodEnd: periodEnd},
+		},
+		{
+			name:    "Payment Failed",
+			current: active,
+			event:   Event{Name: EventPaymentFailed},
+			want:    State{Status: StatusPastDue, PeriodEnd: periodEnd},
+		},
+		{
+			name:    "Payment Failed Twice",
+			current: pastDue,
+			event:   Event{Name: EventPaymentFailed},
+			want:    State{Status: StatusPastDue, PeriodEnd: periodEnd},
+		},
+		{
+			name:    "Grace Period",
+			current: pastDue,
+			event:   Event{Name: EventGracePeriod, GraceUntil: &graceUntil},
+			want:    State{Status: StatusGracePeriod, PeriodEnd: periodEnd, GraceUntil: graceUntil},
+		},
+		{
+			name:    "Grace Period Default",
+			current: active,
+			event:   Event{Name: EventGracePeriod},
+			want:    State{Status: StatusGracePeriod, PeriodEnd: periodEnd, GraceUntil: now.Add(DefaultGracePeriod)},
+		},
+		{
+			name:    "Downgrade",
+			current: grace,
+			event:   Event{Name: EventDowngraded},
+			want:    State{Status: StatusCanceled, PeriodEnd: now},
+		},
+		{
+			name:    "Payment Failed Without Subscription",
+			event:   Event{Name: EventPaymentFailed},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Grace Period Without Subscription",
+			event:   Event{Name: EventGracePeriod},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Downgrade Without Subscription",
+			event:   Event{Name: EventDowngraded},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Payment Failed After Downgrade",
+			current: canceled,
+			event:   Event{Name: EventPaymentFailed},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Grace Period After Downgrade",
+			current: canceled,
+			event:   Event{Name: EventGracePeriod},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Payment Failed After Expiry",
+			current: expired,
+			event:   Event{Name: EventPaymentFailed},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Grace Period After Expiry",
+			current: expired,
+			event:   Event{Name: EventGracePeriod},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Downgrade After Expiry",
+			current: expired,
+			event:   Event{Name: EventDowngraded},
+			wantErr: ErrNotApplicable,
+		},
+		{
+			name:    "Payment Failed After Grace Period",
+			current: grace,
+			event:   Event{Name: EventPaymentFailed},
+			wantErr: ErrNotApplicable,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Next(tc.current, tc.event, now)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNextUnsupportedEvent(t *testing.T) {
+	_, err := Next(nil, Event{Name: "user.deleted"}, time.Now())
+	if err == nil || errors.Is(err, ErrNotApplicable) {
+		t.Errorf("expected an unsupported event error, got %v", err)
+	}
+}
+
+func TestGrantsChirpyRed(t *testing.T) {
+	cases := map[string]bool{
+		StatusActive:      true,
+		StatusPastDue:     true,
+		StatusGracePeriod: true,
+		StatusCanceled:    false,
+		StatusExpired:     false,
+		"":                false,
+	}
+	for status, want := range cases {
+		if got := GrantsChirpyRed(status); got != want {
+			t.Errorf("%q: expected %v, got %v", status, want, got)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package unfurl finds links in chirps and fetches the Open Graph and
+// Twitter card metadata used to render previews of them.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/chirptext"
+	"golang.org/x/net/html"
+)
+
+const (
+	// MaxURLs is how many links are previewed per chirp.
+	MaxURLs = 4
+
+	maxURLLength         = 2048
+	maxRedirects         = 3
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+	userAgent            = "ChirpyBot/1.0 (+link previews)"
+)
+
+// maxBodySize caps how much of a page is read. Metadata lives in <head>, so
+// anything past it is ignored rather than treated as an error.
+var maxBodySize int64 = 512 << 10
+
+var (
+	ErrBlockedAddress = errors.New("unfurl: address is not publicly routable")
+	ErrNotHTML        = errors.New("unfurl: response is not HTML")
+	ErrNoMetadata     = errors.New("unfurl: page has no preview metadata")
+)
+
+// blockedPrefixes are non-public ranges the netip predicates don't cover.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// Preview is the metadata shown for a link.
+type Preview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// ExtractURLs returns the distinct http and https links in body, in order of
+// appearance, up to MaxURLs. Links are found by chirptext.URLs, so what gets
+// unfurled is what counts as a link towards a chirp's length.
+func ExtractURLs(body string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, match := range chirptext.URLs(body) {
+		match = strings.TrimRight(match, ".,;:!?)]}'")
+		if len(match) > maxURLLength || seen[match] {
+			continue
+		}
+		u, err := url.Parse(match)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		seen[match] = true
+		urls = append(urls, match)
+		if len(urls) == MaxURLs {
+			break
+		}
+	}
+	return urls
+}
+
+// NewClient returns an HTTP client for fetching untrusted URLs. It only
+// connects to public addresses on ports 80 and 443, checked after DNS
+// resolution so rebinding can't reach internal services, and gives up after
+// timeout.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: checkAddress,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:            dialer.DialContext,
+			TLSHandshakeTimeout:    timeout,
+			ResponseHeaderTimeout:  timeout,
+			MaxResponseHeaderBytes: 64 << 10,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("unfurl: stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("unfurl: redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if port != "80" && port != "443" {
+		return fmt.Errorf("%w: port %s", ErrBlockedAddress, port)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+	}
+	return nil
+}
+
+// IsPublic reports whether addr is a globally routable unicast address.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Fetch downloads rawURL with client and extracts its preview metadata,
+// preferring Open Graph tags, then Twitter card tags, then <title>.
+func Fetch(ctx context.Context, client *http.Client, rawURL string) (Preview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Preview{}, fmt.Errorf("unfurl: unsupported scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return Preview{}, fmt.Errorf("unfurl: %s responded with %s", rawURL, res.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, ErrNotHTML
+	}
+
+	meta, title := parseHead(io.LimitReader(res.Body, maxBodySize))
+
+	preview := Preview{
+		URL:         rawURL,
+		Title:       firstNonEmpty(meta["og:title"], meta["twitter:title"], title),
+		Description: firstNonEmpty(meta["og:description"], meta["twitter:description"], meta["description"]),
+		SiteName:    meta["og:site_name"],
+	}
+	if image := firstNonEmpty(meta["og:image"], meta["og:image:url"], meta["twitter:image"]); image != "" {
+		preview.ImageURL = resolveImage(res.Request.URL, image)
+	}
+
+	preview.Title = truncate(preview.Title, maxTitleLength)
+	preview.Description = truncate(preview.Description, maxDescriptionLength)
+	preview.SiteName = truncate(preview.SiteName, maxTitleLength)
+
+	if preview.Title == "" && preview.Description == "" {
+		return Preview{}, ErrNoMetadata
+	}
+	return preview, nil
+}
+
+// parseHead collects <meta> tags keyed by property or name, and the page
+// <title>, stopping at </head> or <body>.
+func parseHead(r io.Reader) (map[string]string, string) {
+	meta := make(map[string]string)
+	var title strings.Builder
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return meta, strings.TrimSpace(title.String())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			switch t.Data {
+			case "meta":
+				var key, content string
+				for _, attr := range t.Attr {
+					switch attr.Key {
+					case "property", "name":
+						key = strings.ToLower(attr.Val)
+					case "content":
+						content = strings.TrimSpace(attr.Val)
+					}
+				}
+				if key != "" && content != "" && meta[key] == "" {
+					meta[key] = content
+				}
+			case "title":
+				inTitle = true
+			case "body":
+				return meta, strings.TrimSpace(title.String())
+			}
+		case html.EndTagToken:
+			switch z.Token().Data {
+			case "title":
+				inTitle = false
+			case "head":
+				return meta, strings.TrimSpace(title.String())
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		}
+	}
+}
+
+// resolveImage makes image absolute against the page URL and drops anything
+// that isn't http or https.
+func resolveImage(base *url.URL, image string) string {
+	ref, err := url.Parse(image)
+	if err != nil {
+		return ""
+	}
+	abs := base.ResolveReference(ref)
+	if abs.Scheme != "http" && abs.Scheme != "https" {
+		return ""
+	}
+	if len(abs.String()) > maxURLLength {
+		return ""
+	}
+	return abs.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
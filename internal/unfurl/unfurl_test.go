@@ -0,0 +1,206 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractURLs(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"None", "just a chirp", nil},
+		{"Single", "look at https://example.com/a?b=c", []string{"https://example.com/a?b=c"}},
+		{"Trailing Punctuation", "see (https://example.com/x).", []string{"https://example.com/x"}},
+		{"Duplicates", "http://a.example http://a.example", []string{"http://a.example"}},
+		{"No Host", "broken https:// link", nil},
+		{"Other Scheme", "ftp://example.com", nil},
+		{
+			"Capped",
+			"https://a.example https://b.example https://c.example https://d.example https://e.example",
+			[]string{"https://a.example", "https://b.example", "https://c.example", "https://d.example"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ExtractURLs(tc.body)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.addr, func(t *testing.T) {
+			if got := IsPublic(netip.MustParseAddr(tc.addr)); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		statusCode  int
+		body        string
+		want        Preview
+		wantErr     error
+	}{
+		{
+			name:        "Open Graph",
+			contentType: "text/html; charset=utf-8",
+			body: `<html><head><title>Fallback</title>
+				<meta property="og:title" content="OG Title">
+				<meta property="og:description" content="OG description">
+				<meta property="og:image" content="/img.png">
+				<meta property="og:site_name" content="Example">
+				</head><body></body></html>`,
+			want: Preview{Title: "OG Title", Description: "OG description", ImageURL: "/img.png", SiteName: "Example"},
+		},
+		{
+			name:        "Twitter Card",
+			contentType: "text/html",
+			body: `<head><meta name="twitter:title" content="Card">
+				<meta name="twitter:description" content="Card description">
+				<meta name="twitter:image" content="https://cdn.example/c.png"></head>`,
+			want: Preview{Title: "Card", Description: "Card description", ImageURL: "https://cdn.example/c.png"},
+		},
+		{
+			name:        "Title Only",
+			contentType: "text/html",
+			body:        `<html><head><title> Plain &amp; simple </title></head></html>`,
+			want:        Preview{Title: "Plain & simple"},
+		},
+		{
+			name:        "Ignores Body Meta",
+			contentType: "text/html",
+			body:        `<html><head></head><body><meta property="og:title" content="Late"></body></html>`,
+			wantErr:     ErrNoMetadata,
+		},
+		{
+			name:        "Unsafe Image",
+			contentType: "text/html",
+			body:        `<head><meta property="og:title" content="T"><meta property="og:image" content="javascript:alert(1)"></head>`,
+			want:        Preview{Title: "T"},
+		},
+		{
+			name:        "Not HTML",
+			contentType: "application/json",
+			body:        `{"title":"nope"}`,
+			wantErr:     ErrNotHTML,
+		},
+		{
+			name:        "Not Found",
+			contentType: "text/html",
+			statusCode:  http.StatusNotFound,
+			body:        `<title>Missing</title>`,
+			wantErr:     errAny,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("User-Agent") != userAgent {
+					t.Errorf("expected user agent %q, got %q", userAgent, r.Header.Get("User-Agent"))
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				if tc.statusCode != 0 {
+					w.WriteHeader(tc.statusCode)
+				}
+				fmt.Fprint(w, tc.body)
+			}))
+			defer srv.Close()
+
+			got, err := Fetch(context.Background(), srv.Client(), srv.URL+"/page")
+			if tc.wantErr != nil {
+				if err == nil || (tc.wantErr != errAny && !errors.Is(err, tc.wantErr)) {
+					t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			want := tc.want
+			want.URL = srv.URL + "/page"
+			if strings.HasPrefix(want.ImageURL, "/") {
+				want.ImageURL = srv.URL + want.ImageURL
+			}
+			if got != want {
+				t.Errorf("expected %+v, got %+v", want, got)
+			}
+		})
+	}
+}
+
+// errAny marks cases that only need some error.
+var errAny = errors.New("any error")
+
+func TestFetchLimitsBodySize(t *testing.T) {
+	defer func(n int64) { maxBodySize = n }(maxBodySize)
+	maxBodySize = 1 << 10
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<head><!-- %s --><title>Too far</title></head>", strings.Repeat("x", 2<<10))
+	}))
+	defer srv.Close()
+
+	_, err := Fetch(context.Background(), srv.Client(), srv.URL)
+	if !errors.Is(err, ErrNoMetadata) {
+		t.Errorf("expected %v, got %v", ErrNoMetadata, err)
+	}
+}
+
+func TestNewClientBlocksPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach a loopback server")
+	}))
+	defer srv.Close()
+
+	client := NewClient(time.Second)
+	for _, target := range []string{srv.URL, "http://127.0.0.1/", "http://[::1]/", "http://169.254.169.254/latest/meta-data/"} {
+		_, err := Fetch(context.Background(), client, target)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("%s: expected %v, got %v", target, ErrBlockedAddress, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package validate checks request structs against declarative rules in
+// their `validate` struct tags:
+//
+//	type parameter struct {
+//		Email    string `json:"email" validate:"required,email"`
+//		Password string `json:"password" validate:"required,min=8"`
+//	}
+//
+// Supported rules are required, email, min=N and max=N (characters for
+// strings, elements for slices, value for numbers), and oneof=a b c. Rules
+// other than required are skipped for zero values. Nested structs and
+// pointers to structs are checked too, with dotted field names.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is one rule a field broke. Field is the field's JSON name.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Struct checks v, a struct or pointer to one, and returns every rule it
+// breaks. It panics on malformed tags, which are programming errors.
+func Struct(v any) []FieldError {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+	checkStruct(rv, "", &errs)
+	return errs
+}
+
+func checkStruct(rv reflect.Value, prefix string, errs *[]FieldError) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		name = prefix + name
+		fv := rv.Field(i)
+
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			if err := checkField(fv, name, tag); err != nil {
+				*errs = append(*errs, *err)
+				continue
+			}
+		}
+
+		nested := fv
+		if nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.Type().PkgPath() != "time" {
+			checkStruct(nested, name+".", errs)
+		}
+	}
+}
+
+// checkField applies tag's rules in order and reports the first one fv
+// breaks.
+func checkField(fv reflect.Value, name, tag string) *FieldError {
+	rules := strings.Split(tag, ",")
+	for _, rule := range rules {
+		if rule == "required" {
+			if isEmpty(fv) {
+				return &FieldError{Field: name, Code: "required", Message: name + " is required"}
+			}
+			continue
+		}
+		if fv.IsZero() {
+			return nil
+		}
+
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "email":
+			s := strings.TrimSpace(fv.String())
+			if _, err := mail.ParseAddress(s); err != nil || strings.ContainsAny(s, "<> ") {
+				return &FieldError{Field: name, Code: "invalid_email", Message: name + " must be a valid email address"}
+			}
+		case "min":
+			if size(fv) < mustInt(arg) {
+				return &FieldError{Field: name, Code: "too_short", Message: fmt.Sprintf("%s must be at least %s", name, arg)}
+			}
+		case "max":
+			if size(fv) > mustInt(arg) {
+				return &FieldError{Field: name, Code: "too_long", Message: fmt.Sprintf("%s must be at most %s", name, arg)}
+			}
+		case "oneof":
+			allowed := strings.Fields(arg)
+			s := fmt.Sprint(fv.Interface())
+			found := false
+			for _, a := range allowed {
+				if s == a {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return &FieldError{Field: name, Code: "invalid_choice", Message: fmt.Sprintf("%s must be one of %s", name, strings.Join(allowed, ", "))}
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q on %s", rule, name))
+		}
+	}
+	return nil
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(fv.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return fv.IsNil()
+	}
+	return fv.IsZero()
+}
+
+func size(fv reflect.Value) int {
+	switch fv.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(fv.String())
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return fv.Len()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(fv.Uint())
+	}
+	panic(fmt.Sprintf("validate: min/max on unsupported kind %s", fv.Kind()))
+}
+
+func mustInt(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(fmt.Sprintf("validate: bad number %q", s))
+	}
+	return n
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
@@ -0,0 +1,84 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type poll struct {
+	Options []string `json:"options" validate:"required,min=2,max=4"`
+}
+
+type request struct {
+	Email      string     `json:"email" validate:"required,email"`
+	Name       string     `json:"name" validate:"max=5"`
+	Visibility string     `json:"visibility" validate:"oneof=public private"`
+	Age        int        `json:"age" validate:"min=13"`
+	At         *time.Time `json:"at"`
+	Poll       *poll      `json:"poll"`
+	Ignored    string     `json:"-" validate:"required"`
+	unexported string
+}
+
+func TestStruct(t *testing.T) {
+	cases := []struct {
+		name string
+		req  request
+		want []FieldError
+	}{
+		{
+			name: "Valid",
+			req:  request{Email: "a@example.com", Name: "héllo", Visibility: "public", Age: 20},
+		},
+		{
+			name: "Missing Required",
+			req:  request{Email: "   "},
+			want: []FieldError{{Field: "email", Code: "required", Message: "email is required"}},
+		},
+		{
+			name: "Bad Email",
+			req:  request{Email: "Bob <bob@example.com>"},
+			want: []FieldError{{Field: "email", Code: "invalid_email", Message: "email must be a valid email address"}},
+		},
+		{
+			name: "Several",
+			req:  request{Email: "a@example.com", Name: "toolong", Visibility: "friends", Age: 3},
+			want: []FieldError{
+				{Field: "name", Code: "too_long", Message: "name must be at most 5"},
+				{Field: "visibility", Code: "invalid_choice", Message: "visibility must be one of public, private"},
+				{Field: "age", Code: "too_short", Message: "age must be at least 13"},
+			},
+		},
+		{
+			name: "Nested",
+			req:  request{Email: "a@example.com", Poll: &poll{Options: []string{"one"}}},
+			want: []FieldError{{Field: "poll.options", Code: "too_short", Message: "poll.options must be at least 2"}},
+		},
+		{
+			name: "Nested Required",
+			req:  request{Email: "a@example.com", Poll: &poll{}},
+			want: []FieldError{{Field: "poll.options", Code: "required", Message: "poll.options is required"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Struct(&tc.req)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStructPanicsOnUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	Struct(struct {
+		Name string `validate:"shiny"`
+	}{Name: "x"})
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeliver(t *testing.T) {
+	secret := "secret"
+	payload := []byte(`{"event":"chirp.created"}`)
+
+	cases := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{"Accepted", http.StatusOK, false},
+		{"No Content", http.StatusNoContent, false},
+		{"Server Error", http.StatusInternalServerError, true},
+		{"Gone", http.StatusGone, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Header.Get(EventHeader) != "chirp.created" {
+					t.Errorf("expected event header %q, got %q", "chirp.created", r.Header.Get(EventHeader))
+				}
+				if err := Verify(r.Header.Get(SignatureHeader), body, secret, time.Minute, time.Now()); err != nil {
+					t.Errorf("expected valid signature, got %v", err)
+				}
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer srv.Close()
+
+			statusCode, err := Deliver(context.Background(), srv.Client(), srv.URL, secret, "delivery-1", "chirp.created", payload)
+			if tc.wantErr && err == nil {
+				t.Errorf("expected error for status %d", tc.statusCode)
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if statusCode != tc.statusCode {
+				t.Errorf("expected status %d, got %d", tc.statusCode, statusCode)
+			}
+		})
+	}
+}
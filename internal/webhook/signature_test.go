@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := "secret"
+	body := []byte(`{"id":"evt_1","event":"user.upgraded"}`)
+	now := time.Unix(1700000000, 0)
+	tolerance := 5 * time.Minute
+
+	cases := []struct {
+		name    string
+		header  string
+		body    []byte
+		secret  string
+		wantErr error
+	}{
+		{"Signature Match", Sign(secret, now, body), body, secret, nil},
+		{"Wrong Secret", Sign("other", now, body), body, secret, ErrSignatureMismatch},
+		{"Tampered Body", Sign(secret, now, body), []byte(`{"id":"evt_2"}`), secret, ErrSignatureMismatch},
+		{"Stale Timestamp", Sign(secret, now.Add(-10*time.Minute), body), body, secret, ErrStaleTimestamp},
+		{"Future Timestamp", Sign(secret, now.Add(10*time.Minute), body), body, secret, ErrStaleTimestamp},
+		{"Missing Header", "", body, secret, ErrMissingSignature},
+		{"Missing Timestamp", "v1=abcdef", body, secret, ErrMalformedSignature},
+		{"Garbage Header", "not-a-signature", body, secret, ErrMalformedSignature},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.header, tc.body, tc.secret, tolerance, now)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/unfurl"
+)
+
+var ErrInvalidURL = errors.New("webhook url must be a public http or https endpoint")
+
+// lookupNetIP resolves endpoint hosts. Tests replace it to avoid DNS.
+var lookupNetIP = func(ctx context.Context, host string) ([]netip.Addr, error) {
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// NewClient returns the client deliveries are sent with. It shares the link
+// unfurler's guard, so endpoints that resolve to loopback, link-local or
+// private addresses are refused at connect time, after DNS resolution.
+func NewClient(timeout time.Duration) *http.Client {
+	return unfurl.NewClient(timeout)
+}
+
+// ValidateURL checks that rawURL is an endpoint deliveries can reach: http
+// or https on the default port, with a host that resolves only to public
+// addresses. NewClient re-checks at delivery time, since DNS can change.
+func ValidateURL(ctx context.Context, rawURL string) (*url.URL, error) {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return nil, ErrInvalidURL
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		return nil, fmt.Errorf("%w: port %s is not allowed", ErrInvalidURL, port)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, fmt.Errorf("%w: %s is not public", ErrInvalidURL, host)
+	}
+
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		addrs, err = lookupNetIP(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return nil, fmt.Errorf("%w: %s does not resolve", ErrInvalidURL, host)
+		}
+	}
+	for _, addr := range addrs {
+		if !unfurl.IsPublic(addr) {
+			return nil, fmt.Errorf("%w: %s is not public", ErrInvalidURL, host)
+		}
+	}
+
+	return u, nil
+}
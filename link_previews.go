@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/retry"
+	"github.com/Sanghun1Adam1Park/chirp/internal/unfurl"
+	"github.com/google/uuid"
+)
+
+const (
+	linkPreviewStatusPending = "pending"
+	linkPreviewStatusFailed  = "failed"
+)
+
+const (
+	linkPreviewMaxAttempts  = 3
+	linkPreviewBatchSize    = 10
+	linkPreviewFetchTimeout = 5 * time.Second
+)
+
+type linkPreviewResponse struct {
+	Url         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	ImageUrl    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// queueLinkPreviews records the links in a new chirp and queues any that
+// aren't cached yet for the unfurler. The caller owns the transaction.
+func queueLinkPreviews(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for i, url := range unfurl.ExtractURLs(chirp.Body) {
+		if err := q.QueueLinkPreview(ctx, url); err != nil {
+			return err
+		}
+
+		err := q.AddChirpLink(ctx,
+			database.AddChirpLinkParams{
+				ChirpID:  chirp.ID,
+				Url:      url,
+				Position: int32(i),
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachLinkPreviews fills in the previews that are ready for each chirp.
+func attachLinkPreviews(ctx context.Context, q *database.Queries, chirps []chirpResponse) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.Id)
+	}
+
+	rows, err := q.GetLinkPreviewsByChirpIds(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	previews := make(map[uuid.UUID][]linkPreviewResponse)
+	for _, row := range rows {
+		previews[row.ChirpID] = append(previews[row.ChirpID], linkPreviewResponse{
+			Url:         row.Url,
+			Title:       row.Title,
+			Description: row.Description,
+			ImageUrl:    row.ImageUrl,
+			SiteName:    row.SiteName,
+		})
+	}
+
+	for i := range chirps {
+		chirps[i].LinkPreviews = previews[chirps[i].Id]
+	}
+	return nil
+}
+
+// runLinkUnfurler fetches queued link previews on every tick until ctx is
+// done.
+func (cfg *apiConfig) runLinkUnfurler(ctx context.Context, interval time.Duration) {
+	client := unfurl.NewClient(linkPreviewFetchTimeout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.unfurlLinks(ctx, client)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) unfurlLinks(ctx context.Context, client *http.Client) {
+	previews, err := cfg.queries.ClaimDueLinkPreviews(ctx, linkPreviewBatchSize)
+	if err != nil {
+		log.Printf("Error claiming link previews: %s", err)
+		return
+	}
+
+	for _, preview := range previews {
+		meta, err := unfurl.Fetch(ctx, client, preview.Url)
+		if err == nil {
+			err = cfg.queries.MarkLinkPreviewReady(ctx,
+				database.MarkLinkPreviewReadyParams{
+					Url:         preview.Url,
+					Title:       meta.Title,
+					Description: meta.Description,
+					ImageUrl:    meta.ImageURL,
+					SiteName:    meta.SiteName,
+				},
+			)
+			if err != nil {
+				log.Printf("Error saving link preview %s: %s", preview.Url, err)
+			}
+			continue
+		}
+
+		attempts := preview.Attempts + 1
+		next := database.MarkLinkPreviewFailedParams{
+			Url:           preview.Url,
+			Status:        linkPreviewStatusPending,
+			Attempts:      attempts,
+			NextAttemptAt: time.Now().Add(retry.Backoff(int(attempts))),
+			LastError:     sql.NullString{String: err.Error(), Valid: true},
+		}
+		// Blocked addresses and pages without metadata won't change on a
+		// retry, so only network and server errors are tried again.
+		if attempts >= linkPreviewMaxAttempts || isPermanentUnfurlError(err) {
+			next.Status = linkPreviewStatusFailed
+		}
+
+		if err := cfg.queries.MarkLinkPreviewFailed(ctx, next); err != nil {
+			log.Printf("Error recording link preview failure %s: %s", preview.Url, err)
+		}
+	}
+}
+
+func isPermanentUnfurlError(err error) bool {
+	return errors.Is(err, unfurl.ErrBlockedAddress) ||
+		errors.Is(err, unfurl.ErrNotHTML) ||
+		errors.Is(err, unfurl.ErrNoMetadata)
+}
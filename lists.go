@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxListNameLength = 100
+	maxListMembers    = 500
+)
+
+type listResponse struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserId    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+}
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	name, ok := decodeListName(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := cfg.queries.CreateList(r.Context(),
+		database.CreateListParams{
+			UserID: userId,
+			Name:   name,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toListResponse(list), http.StatusCreated)
+}
+
+func (cfg *apiConfig) handlerGetLists(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	lists, err := cfg.queries.GetListsByUserId(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]listResponse, 0, len(lists))
+	for _, list := range lists {
+		responses = append(responses, toListResponse(list))
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, r *http.Request) {
+	list, _, ok := cfg.getOwnedList(w, r, cfg.queries.GetListById)
+	if !ok {
+		return
+	}
+
+	writeSuccessResponse(w, toListResponse(list), http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerUpdateList(w http.ResponseWriter, r *http.Request) {
+	name, ok := decodeListName(w, r)
+	if !ok {
+		return
+	}
+
+	list, _, ok := cfg.getOwnedList(w, r, cfg.queries.GetListById)
+	if !ok {
+		return
+	}
+
+	list, err := cfg.queries.UpdateList(r.Context(),
+		database.UpdateListParams{
+			ID:   list.ID,
+			Name: name,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toListResponse(list), http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, r *http.Request) {
+	list, _, ok := cfg.getOwnedList(w, r, cfg.queries.GetListById)
+	if !ok {
+		return
+	}
+
+	if err := cfg.queries.DeleteList(r.Context(), list.ID); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, r *http.Request) {
+	list, _, ok := cfg.getOwnedList(w, r, cfg.queries.GetListById)
+	if !ok {
+		return
+	}
+
+	members, err := cfg.queries.GetListMembers(r.Context(), list.ID)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]relationResponse, 0, len(members))
+	for _, member := range members {
+		responses = append(responses, relationResponse{
+			UserId:    member.MemberID,
+			CreatedAt: member.CreatedAt,
+		})
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+// handlerAddListMember adds user_id to one of the caller's lists. Users who
+// have blocked the caller, or whom the caller has blocked, can't be added.
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		UserId uuid.UUID `json:"user_id"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// The lock keeps concurrent adds from overshooting maxListMembers.
+	list, userId, ok := cfg.getOwnedList(w, r, qtx.LockListById)
+	if !ok {
+		return
+	}
+
+	if _, err := qtx.GetUserById(r.Context(), param.UserId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("user not found"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	blocked, err := isBlockedEitherWay(r.Context(), qtx, userId, param.UserId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		writeErrorResponse(w, errors.New("cannot add this user"), http.StatusForbidden)
+		return
+	}
+
+	count, err := qtx.CountListMembers(r.Context(), list.ID)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if count >= maxListMembers {
+		writeErrorResponse(w, fmt.Errorf("lists can have at most %d members", maxListMembers), http.StatusConflict)
+		return
+	}
+
+	_, err = qtx.AddListMember(r.Context(),
+		database.AddListMemberParams{
+			ListID:   list.ID,
+			MemberID: param.UserId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, r *http.Request) {
+	list, _, ok := cfg.getOwnedList(w, r, cfg.queries.GetListById)
+	if !ok {
+		return
+	}
+
+	memberIdStr := r.PathValue("userId")
+	memberId, err := uuid.Parse(memberIdStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid user id %q: %w", memberIdStr, err), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := cfg.queries.RemoveListMember(r.Context(),
+		database.RemoveListMemberParams{
+			ListID:   list.ID,
+			MemberID: memberId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if removed == 0 {
+		writeErrorResponse(w, fmt.Errorf("user not in list"), http.StatusNotFound)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerGetListTimeline pages through chirps by the list's members, newest
+// first, as a plain array paginated like GET /api/chirps?sort=desc.
+// Mutes don't apply: adding someone to a list is asking to read them.
+func (cfg *apiConfig) handlerGetListTimeline(w http.ResponseWriter, r *http.Request) {
+	list, userId, ok := cfg.getOwnedList(w, r, cfg.queries.GetListById)
+	if !ok {
+		return
+	}
+
+	limit, err := parseChirpPageLimit(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	before, err := parsePageCursor(r, "before")
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	params := database.GetListTimelineParams{
+		ListID:   list.ID,
+		ViewerID: userId,
+		RowLimit: int32(limit),
+	}
+	if before != nil {
+		params.BeforeTime = sql.NullTime{Time: time.UnixMicro(before.Key), Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: before.ID, Valid: true}
+	}
+
+	chirps, err := cfg.queries.GetListTimeline(r.Context(), params)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	page := toChirpPageResponse(chirps, limit)
+	if err := attachChirpDetails(r.Context(), cfg.queries, uuid.NullUUID{UUID: userId, Valid: true}, page.Chirps); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeChirpList(w, page)
+}
+
+// getOwnedList loads the list named by the {id} path value with load and
+// checks the caller owns it. Lists are private, so anyone else gets a 404.
+// It writes the error response itself.
+func (cfg *apiConfig) getOwnedList(w http.ResponseWriter, r *http.Request, load func(context.Context, uuid.UUID) (database.List, error)) (database.List, uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.List{}, uuid.Nil, false
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.List{}, uuid.Nil, false
+	}
+
+	idStr := r.PathValue("id")
+	listId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return database.List{}, uuid.Nil, false
+	}
+
+	list, err := load(r.Context(), listId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("list not found"), http.StatusNotFound)
+			return database.List{}, uuid.Nil, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.List{}, uuid.Nil, false
+	}
+
+	if list.UserID != userId {
+		writeErrorResponse(w, fmt.Errorf("list not found"), http.StatusNotFound)
+		return database.List{}, uuid.Nil, false
+	}
+
+	return list, userId, true
+}
+
+func decodeListName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	type parameter struct {
+		Name string `json:"name"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return "", false
+	}
+
+	name := strings.TrimSpace(param.Name)
+	if name == "" {
+		writeErrorResponse(w, errors.New("name is required"), http.StatusBadRequest)
+		return "", false
+	}
+	if len(name) > maxListNameLength {
+		writeErrorResponse(w, fmt.Errorf("name must be at most %d characters", maxListNameLength), http.StatusBadRequest)
+		return "", false
+	}
+
+	return name, true
+}
+
+func toListResponse(list database.List) listResponse {
+	return listResponse{
+		Id:        list.ID,
+		CreatedAt: list.CreatedAt,
+		UpdatedAt: list.UpdatedAt,
+		UserId:    list.UserID,
+		Name:      list.Name,
+	}
+}
@@ -1,23 +1,32 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
 	"sync/atomic"
+	"time"
 
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
 	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/mailer"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	queries        *database.Queries
-	platform       string
-	secret         string
-	polka_key      string
+	fileserverHits  atomic.Int32
+	db              *sql.DB
+	queries         *database.Queries
+	platform        string
+	secret          string
+	polka_key       string
+	chirpStream     *chirpStream
+	notificationHub *notificationHub
+	mailer          mailer.Mailer
+	baseURL         string
 }
 
 func main() {
@@ -29,6 +38,15 @@ func main() {
 	platform := os.Getenv("PLATFORM")
 	secret := os.Getenv("SECRET")
 	polka_key := os.Getenv("POLKA_KEY")
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:" + port
+	}
+
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		mail = mailer.NewSMTPMailer(smtpAddr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	}
 
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
@@ -37,42 +55,163 @@ func main() {
 	}
 
 	dbQueries := database.New(db)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "grant-admin":
+			if len(os.Args) != 3 {
+				log.Fatal("usage: chirpy grant-admin <email>")
+			}
+			if err := grantAdmin(context.Background(), dbQueries, os.Args[2]); err != nil {
+				log.Fatalf("error granting admin: %v", err)
+			}
+		default:
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+		return
+	}
+
 	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		queries:        dbQueries,
-		platform:       platform,
-		secret:         secret,
-		polka_key:      polka_key,
+		fileserverHits:  atomic.Int32{},
+		db:              db,
+		queries:         dbQueries,
+		platform:        platform,
+		secret:          secret,
+		polka_key:       polka_key,
+		chirpStream:     newChirpStream(dbQueries),
+		notificationHub: newNotificationHub(),
+		mailer:          mail,
+		baseURL:         baseURL,
 	}
 
 	mux := http.NewServeMux()
 	fileHandler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
 	mux.Handle("/app/", fileHandler)
 
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	mux.Handle("GET /admin/metrics", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerMetrics))
 
 	mux.HandleFunc("GET /api/healthz", apiCfg.handlerReadiness)
 
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.Handle("POST /admin/reset", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerReset))
 	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
+	mux.Handle("POST /api/chirps", apiCfg.middlewareActiveAccount(apiCfg.handlerCreateChirp))
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
 	mux.HandleFunc("GET /api/chirps/{id}", apiCfg.handlerGetChirp)
+	mux.Handle("POST /api/chirps/{id}/reports", apiCfg.middlewareActiveAccount(apiCfg.handlerReportChirp))
+	mux.Handle("POST /api/chirps/{id}/bookmark", apiCfg.middlewareActiveAccount(apiCfg.handlerBookmarkChirp))
+	mux.Handle("DELETE /api/chirps/{id}/bookmark", apiCfg.middlewareActiveAccount(apiCfg.handlerUnbookmarkChirp))
+	mux.Handle("POST /api/chirps/{id}/poll/votes", apiCfg.middlewareActiveAccount(apiCfg.handlerVotePoll))
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.handlerGetBookmarks)
+	mux.Handle("POST /api/chirps/{chirpID}/pin", apiCfg.middlewareActiveAccount(apiCfg.handlerPinChirp))
+	mux.Handle("DELETE /api/chirps/{chirpID}/pin", apiCfg.middlewareActiveAccount(apiCfg.handlerUnpinChirp))
+	mux.HandleFunc("GET /api/users/{id}/pins", apiCfg.handlerGetPinnedChirps)
+
+	mux.Handle("POST /api/collections", apiCfg.middlewareActiveAccount(apiCfg.handlerCreateCollection))
+	mux.HandleFunc("GET /api/collections", apiCfg.handlerGetCollections)
+	mux.HandleFunc("GET /api/collections/{id}", apiCfg.handlerGetCollection)
+	mux.Handle("PUT /api/collections/{id}", apiCfg.middlewareActiveAccount(apiCfg.handlerUpdateCollection))
+	mux.Handle("DELETE /api/collections/{id}", apiCfg.middlewareActiveAccount(apiCfg.handlerDeleteCollection))
+	mux.HandleFunc("GET /api/collections/{id}/chirps", apiCfg.handlerGetCollectionChirps)
+	mux.Handle("POST /api/collections/{id}/chirps", apiCfg.middlewareActiveAccount(apiCfg.handlerAddCollectionChirp))
+	mux.Handle("PUT /api/collections/{id}/chirps", apiCfg.middlewareActiveAccount(apiCfg.handlerReorderCollection))
+	mux.Handle("DELETE /api/collections/{id}/chirps/{chirpId}", apiCfg.middlewareActiveAccount(apiCfg.handlerRemoveCollectionChirp))
+	mux.HandleFunc("GET /api/users/{id}/collections", apiCfg.handlerGetUserCollections)
+
+	mux.Handle("POST /api/lists", apiCfg.middlewareActiveAccount(apiCfg.handlerCreateList))
+	mux.HandleFunc("GET /api/lists", apiCfg.handlerGetLists)
+	mux.HandleFunc("GET /api/lists/{id}", apiCfg.handlerGetList)
+	mux.Handle("PUT /api/lists/{id}", apiCfg.middlewareActiveAccount(apiCfg.handlerUpdateList))
+	mux.Handle("DELETE /api/lists/{id}", apiCfg.middlewareActiveAccount(apiCfg.handlerDeleteList))
+	mux.HandleFunc("GET /api/lists/{id}/members", apiCfg.handlerGetListMembers)
+	mux.Handle("POST /api/lists/{id}/members", apiCfg.middlewareActiveAccount(apiCfg.handlerAddListMember))
+	mux.Handle("DELETE /api/lists/{id}/members/{userId}", apiCfg.middlewareActiveAccount(apiCfg.handlerRemoveListMember))
+	mux.HandleFunc("GET /api/lists/{id}/timeline", apiCfg.handlerGetListTimeline)
+
+	mux.Handle("POST /api/drafts", apiCfg.middlewareActiveAccount(apiCfg.handlerCreateDraft))
+	mux.HandleFunc("GET /api/drafts", apiCfg.handlerGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{id}", apiCfg.handlerGetDraft)
+	mux.Handle("PUT /api/drafts/{id}", apiCfg.middlewareActiveAccount(apiCfg.handlerUpdateDraft))
+	mux.Handle("DELETE /api/drafts/{id}", apiCfg.middlewareActiveAccount(apiCfg.handlerDeleteDraft))
+	mux.Handle("POST /api/drafts/{id}/publish", apiCfg.middlewareActiveAccount(apiCfg.handlerPublishDraft))
+	mux.HandleFunc("GET /api/stream/chirps", apiCfg.handlerStreamChirps)
+	mux.HandleFunc("GET /api/gateway", apiCfg.handlerGateway)
 
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
 
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateCrendentials)
-
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
-
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUpdateChirpyRed)
+	mux.Handle("PATCH /api/users/email", apiCfg.middlewareActiveAccount(apiCfg.handlerChangeEmail))
+	mux.Handle("POST /api/users/email/confirm", apiCfg.middlewareActiveAccount(apiCfg.handlerConfirmEmailChange))
+	mux.Handle("PATCH /api/users/password", apiCfg.middlewareActiveAccount(apiCfg.handlerChangePassword))
+	mux.Handle("PUT /api/users/handle", apiCfg.middlewareActiveAccount(apiCfg.handlerUpdateHandle))
+	mux.HandleFunc("DELETE /api/users/me", apiCfg.handlerDeleteAccount)
+	mux.HandleFunc("DELETE /api/users/me/deletion", apiCfg.handlerCancelAccountDeletion)
+	mux.HandleFunc("POST /api/users/me/exports", apiCfg.handlerCreateDataExport)
+	mux.HandleFunc("GET /api/users/me/exports/{id}", apiCfg.handlerGetDataExport)
+	mux.HandleFunc("GET /api/users/me/exports/{id}/download", apiCfg.handlerDownloadDataExport)
+
+	mux.Handle("DELETE /api/chirps/{chirpID}", apiCfg.middlewareActiveAccount(apiCfg.handlerDeleteChirp))
+
+	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerPolkaWebhook)
+
+	mux.Handle("POST /api/webhooks", apiCfg.middlewareActiveAccount(apiCfg.handlerCreateWebhook))
+	mux.HandleFunc("GET /api/webhooks", apiCfg.handlerGetWebhooks)
+	mux.Handle("DELETE /api/webhooks/{id}", apiCfg.middlewareActiveAccount(apiCfg.handlerDeleteWebhook))
+	mux.HandleFunc("GET /api/webhooks/{id}/deliveries", apiCfg.handlerGetWebhookDeliveries)
+
+	mux.Handle("POST /api/users/{id}/follow", apiCfg.middlewareActiveAccount(apiCfg.handlerFollowUser))
+	mux.Handle("DELETE /api/users/{id}/follow", apiCfg.middlewareActiveAccount(apiCfg.handlerUnfollowUser))
+	mux.HandleFunc("GET /api/follow-requests", apiCfg.handlerGetFollowRequests)
+	mux.Handle("POST /api/follow-requests/{id}/approve", apiCfg.middlewareActiveAccount(apiCfg.handlerApproveFollowRequest))
+	mux.Handle("POST /api/follow-requests/{id}/deny", apiCfg.middlewareActiveAccount(apiCfg.handlerDenyFollowRequest))
+	mux.Handle("PUT /api/users/privacy", apiCfg.middlewareActiveAccount(apiCfg.handlerUpdatePrivacy))
+	mux.Handle("PUT /api/users/dm-policy", apiCfg.middlewareActiveAccount(apiCfg.handlerUpdateDmPolicy))
+	mux.Handle("POST /api/users/{id}/block", apiCfg.middlewareActiveAccount(apiCfg.handlerBlockUser))
+	mux.Handle("DELETE /api/users/{id}/block", apiCfg.middlewareActiveAccount(apiCfg.handlerUnblockUser))
+	mux.HandleFunc("GET /api/blocks", apiCfg.handlerGetBlocks)
+	mux.Handle("POST /api/users/{id}/mute", apiCfg.middlewareActiveAccount(apiCfg.handlerMuteUser))
+	mux.Handle("DELETE /api/users/{id}/mute", apiCfg.middlewareActiveAccount(apiCfg.handlerUnmuteUser))
+	mux.HandleFunc("GET /api/mutes", apiCfg.handlerGetMutes)
+	mux.Handle("POST /api/users/{id}/reports", apiCfg.middlewareActiveAccount(apiCfg.handlerReportUser))
+
+	mux.Handle("POST /api/conversations", apiCfg.middlewareActiveAccount(apiCfg.handlerCreateConversation))
+	mux.HandleFunc("GET /api/conversations", apiCfg.handlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/{id}", apiCfg.handlerGetConversation)
+	mux.Handle("POST /api/conversations/{id}/messages", apiCfg.middlewareActiveAccount(apiCfg.handlerCreateMessage))
+	mux.HandleFunc("GET /api/conversations/{id}/messages", apiCfg.handlerGetMessages)
+	mux.Handle("POST /api/conversations/{id}/read", apiCfg.middlewareActiveAccount(apiCfg.handlerMarkConversationRead))
+
+	mux.Handle("GET /admin/moderation/reports", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerGetModerationQueue))
+	mux.Handle("POST /admin/moderation/reports/{id}/claim", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerClaimReport))
+	mux.Handle("POST /admin/moderation/reports/{id}/resolve", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerResolveReport))
+	mux.Handle("GET /admin/moderation/actions", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerGetModerationActions))
+	mux.Handle("PUT /admin/users/{id}/role", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerUpdateUserRole))
+	mux.Handle("POST /admin/users/{id}/suspend", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerSuspendUser))
+	mux.Handle("POST /admin/users/{id}/ban", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerBanUser))
+	mux.Handle("POST /admin/users/{id}/reinstate", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerReinstateUser))
+	mux.Handle("GET /admin/appeals", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerGetAppeals))
+	mux.Handle("POST /admin/appeals/{id}/resolve", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handlerResolveAppeal))
+	mux.HandleFunc("POST /api/appeals", apiCfg.handlerCreateAppeal)
+
+	mux.HandleFunc("GET /api/notifications", apiCfg.handlerGetNotifications)
+	mux.Handle("POST /api/notifications/{id}/read", apiCfg.middlewareActiveAccount(apiCfg.handlerMarkNotificationRead))
+	mux.Handle("POST /api/notifications/read", apiCfg.middlewareActiveAccount(apiCfg.handlerMarkAllNotificationsRead))
+	mux.HandleFunc("GET /api/notifications/preferences", apiCfg.handlerGetNotificationPreferences)
+	mux.Handle("PUT /api/notifications/preferences", apiCfg.middlewareActiveAccount(apiCfg.handlerUpdateNotificationPreferences))
+
+	go apiCfg.runSubscriptionExpiry(context.Background(), time.Minute)
+	go apiCfg.runWebhookDispatcher(context.Background(), 5*time.Second)
+	go apiCfg.runChirpScheduler(context.Background(), 15*time.Second)
+	go apiCfg.runLinkUnfurler(context.Background(), 5*time.Second)
+	go apiCfg.runAccountDeletion(context.Background(), time.Hour)
+	go apiCfg.runDataExportWorker(context.Background(), 10*time.Second)
+	go apiCfg.chirpStream.run(context.Background(), dbURL)
+	go apiCfg.notificationHub.run(context.Background(), dbURL)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: middlewareRequestID(mux),
 	}
 
 	log.Printf("Serving files from %s on port: %s\n", filepathRoot, port)
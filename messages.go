@@ -0,0 +1,601 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/chirptext"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/dberr"
+	"github.com/google/uuid"
+)
+
+const (
+	dmPolicyEveryone  = "everyone"
+	dmPolicyFollowing = "following"
+)
+
+const (
+	maxConversationMembers = 8
+	maxMessageLength       = 2000
+	defaultMessageLimit    = 50
+	maxMessageLimit        = 100
+)
+
+type conversationMemberResponse struct {
+	UserId     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type conversationResponse struct {
+	Id          uuid.UUID                    `json:"id"`
+	CreatedAt   time.Time                    `json:"created_at"`
+	UpdatedAt   time.Time                    `json:"updated_at"`
+	CreatedBy   *uuid.UUID                   `json:"created_by"`
+	Members     []conversationMemberResponse `json:"members"`
+	UnreadCount int64                        `json:"unread_count"`
+}
+
+type messageResponse struct {
+	Id             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationId uuid.UUID `json:"conversation_id"`
+	SenderId       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		MemberIds []uuid.UUID `json:"member_ids"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	seen := map[uuid.UUID]bool{userId: true}
+	recipients := make([]uuid.UUID, 0, len(param.MemberIds))
+	for _, memberId := range param.MemberIds {
+		if seen[memberId] {
+			continue
+		}
+		seen[memberId] = true
+		recipients = append(recipients, memberId)
+	}
+
+	if len(recipients) == 0 {
+		writeErrorResponse(w, errors.New("at least one other member is required"), http.StatusBadRequest)
+		return
+	}
+	if len(recipients)+1 > maxConversationMembers {
+		writeErrorResponse(w, fmt.Errorf("conversations are limited to %d members", maxConversationMembers), http.StatusBadRequest)
+		return
+	}
+
+	for _, recipientId := range recipients {
+		recipient, err := cfg.queries.GetUserById(r.Context(), recipientId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeErrorResponse(w, fmt.Errorf("user %s not found", recipientId), http.StatusNotFound)
+				return
+			}
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		blocked, err := isBlockedEitherWay(r.Context(), cfg.queries, userId, recipient.ID)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+		if blocked {
+			writeErrorResponse(w, fmt.Errorf("cannot message user %s", recipientId), http.StatusForbidden)
+			return
+		}
+
+		if recipient.DmPolicy == dmPolicyFollowing {
+			follows, err := cfg.queries.IsFollowing(r.Context(),
+				database.IsFollowingParams{
+					FollowerID: recipient.ID,
+					FolloweeID: userId,
+				},
+			)
+			if err != nil {
+				writeErrorResponse(w, err, http.StatusInternalServerError)
+				return
+			}
+			if !follows {
+				writeErrorResponse(w, fmt.Errorf("user %s only accepts messages from people they follow", recipientId), http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	var directKey sql.NullString
+	if len(recipients) == 1 {
+		directKey = sql.NullString{String: directConversationKey(userId, recipients[0]), Valid: true}
+		existing, err := cfg.queries.GetDirectConversation(r.Context(), directKey)
+		if err == nil {
+			res, err := cfg.conversationResponse(r, existing, userId)
+			if err != nil {
+				writeErrorResponse(w, err, http.StatusInternalServerError)
+				return
+			}
+			writeSuccessResponse(w, res, http.StatusOK)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	// Two requests racing to start the same direct conversation hit the
+	// unique direct_key; the loser gets a 409 and finds it on retry.
+	conversation, err := qtx.CreateConversation(r.Context(),
+		database.CreateConversationParams{
+			CreatedBy: uuid.NullUUID{UUID: userId, Valid: true},
+			DirectKey: directKey,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	for _, memberId := range append([]uuid.UUID{userId}, recipients...) {
+		err := qtx.AddConversationMember(r.Context(),
+			database.AddConversationMemberParams{
+				ConversationID: conversation.ID,
+				UserID:         memberId,
+			},
+		)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	res, err := cfg.conversationResponse(r, conversation, userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeSuccessResponse(w, res, http.StatusCreated)
+}
+
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	conversations, err := cfg.queries.GetConversationsByUserId(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]conversationResponse, 0, len(conversations))
+	for _, conversation := range conversations {
+		res, err := cfg.conversationResponse(r, conversation, userId)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, res)
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerGetConversation(w http.ResponseWriter, r *http.Request) {
+	conversation, userId, ok := cfg.getMemberConversation(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := cfg.conversationResponse(r, conversation, userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeSuccessResponse(w, res, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerCreateMessage(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Body string `json:"body"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(param.Body) == "" {
+		writeErrorResponse(w, errors.New("message body is required"), http.StatusBadRequest)
+		return
+	}
+	if chirptext.Length(param.Body) > maxMessageLength {
+		writeErrorResponse(w, errors.New("Message is too long"), http.StatusBadRequest)
+		return
+	}
+
+	conversation, userId, ok := cfg.getMemberConversation(w, r)
+	if !ok {
+		return
+	}
+
+	blocked, err := cfg.queries.IsBlockedByConversationMember(r.Context(),
+		database.IsBlockedByConversationMemberParams{
+			UserID:         userId,
+			ConversationID: conversation.ID,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		writeErrorResponse(w, errors.New("cannot message a conversation with a blocked user"), http.StatusForbidden)
+		return
+	}
+
+	refused, err := cfg.queries.IsRefusedByConversationMember(r.Context(),
+		database.IsRefusedByConversationMemberParams{
+			ConversationID: conversation.ID,
+			SenderID:       userId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if refused {
+		writeErrorResponse(w, errors.New("a member of this conversation only accepts messages from people they follow"), http.StatusForbidden)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	message, err := qtx.CreateMessage(r.Context(),
+		database.CreateMessageParams{
+			ConversationID: conversation.ID,
+			SenderID:       userId,
+			Body:           param.Body,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := qtx.TouchConversation(r.Context(), conversation.ID); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = qtx.MarkConversationRead(r.Context(),
+		database.MarkConversationReadParams{
+			ReadAt:         message.CreatedAt,
+			ConversationID: conversation.ID,
+			UserID:         userId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toMessageResponse(message), http.StatusCreated)
+}
+
+func (cfg *apiConfig) handlerGetMessages(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Messages   []messageResponse `json:"messages"`
+		NextCursor *uuid.UUID        `json:"next_cursor"`
+	}
+
+	limit := defaultMessageLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxMessageLimit {
+			writeErrorResponse(w, fmt.Errorf("invalid limit %q", limitParam), http.StatusBadRequest)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	var before uuid.NullUUID
+	if beforeParam := r.URL.Query().Get("before"); beforeParam != "" {
+		parsedBefore, err := uuid.Parse(beforeParam)
+		if err != nil {
+			writeErrorResponse(w, fmt.Errorf("invalid before %q: %w", beforeParam, err), http.StatusBadRequest)
+			return
+		}
+		before = uuid.NullUUID{UUID: parsedBefore, Valid: true}
+	}
+
+	conversation, _, ok := cfg.getMemberConversation(w, r)
+	if !ok {
+		return
+	}
+
+	messages, err := cfg.queries.GetMessages(r.Context(),
+		database.GetMessagesParams{
+			ConversationID: conversation.ID,
+			Before:         before,
+			RowLimit:       int32(limit),
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := response{
+		Messages: make([]messageResponse, 0, len(messages)),
+	}
+	for _, message := range messages {
+		res.Messages = append(res.Messages, toMessageResponse(message))
+	}
+	if len(messages) == limit {
+		res.NextCursor = &messages[len(messages)-1].ID
+	}
+
+	writeSuccessResponse(w, res, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		MessageId uuid.UUID `json:"message_id"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	conversation, userId, ok := cfg.getMemberConversation(w, r)
+	if !ok {
+		return
+	}
+
+	message, err := cfg.queries.GetMessageById(r.Context(), param.MessageId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if err != nil || message.ConversationID != conversation.ID {
+		writeErrorResponse(w, fmt.Errorf("message not found"), http.StatusNotFound)
+		return
+	}
+
+	err = cfg.queries.MarkConversationRead(r.Context(),
+		database.MarkConversationReadParams{
+			ReadAt:         message.CreatedAt,
+			ConversationID: conversation.ID,
+			UserID:         userId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUpdateDmPolicy(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		DmPolicy string `json:"dm_policy"`
+	}
+	type response struct {
+		DmPolicy string `json:"dm_policy"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	if param.DmPolicy != dmPolicyEveryone && param.DmPolicy != dmPolicyFollowing {
+		writeErrorResponse(w, fmt.Errorf("invalid dm_policy %q", param.DmPolicy), http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.UpdateUserDmPolicy(r.Context(),
+		database.UpdateUserDmPolicyParams{
+			ID:       userId,
+			DmPolicy: param.DmPolicy,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusNotFound)
+		return
+	}
+
+	writeSuccessResponse(w, response{DmPolicy: user.DmPolicy}, http.StatusOK)
+}
+
+// getMemberConversation loads the conversation named by the {id} path value
+// and checks that the caller is one of its members. On failure it writes the
+// error response itself and returns false.
+func (cfg *apiConfig) getMemberConversation(w http.ResponseWriter, r *http.Request) (database.Conversation, uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	conversation, err := cfg.queries.GetConversationById(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("conversation not found"), http.StatusNotFound)
+			return database.Conversation{}, uuid.Nil, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.Conversation{}, uuid.Nil, false
+	}
+
+	members, err := cfg.queries.GetConversationMembers(r.Context(), conversation.ID)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.Conversation{}, uuid.Nil, false
+	}
+	for _, member := range members {
+		if member.UserID == userId {
+			return conversation, userId, true
+		}
+	}
+
+	// Non-members get the same answer as for a missing conversation so that
+	// conversation ids can't be probed.
+	writeErrorResponse(w, fmt.Errorf("conversation not found"), http.StatusNotFound)
+	return database.Conversation{}, uuid.Nil, false
+}
+
+func (cfg *apiConfig) conversationResponse(r *http.Request, conversation database.Conversation, userId uuid.UUID) (conversationResponse, error) {
+	members, err := cfg.queries.GetConversationMembers(r.Context(), conversation.ID)
+	if err != nil {
+		return conversationResponse{}, err
+	}
+
+	unreadCount, err := cfg.queries.CountUnreadMessages(r.Context(),
+		database.CountUnreadMessagesParams{
+			ConversationID: conversation.ID,
+			UserID:         userId,
+		},
+	)
+	if err != nil {
+		return conversationResponse{}, err
+	}
+
+	res := conversationResponse{
+		Id:          conversation.ID,
+		CreatedAt:   conversation.CreatedAt,
+		UpdatedAt:   conversation.UpdatedAt,
+		Members:     make([]conversationMemberResponse, 0, len(members)),
+		UnreadCount: unreadCount,
+	}
+	if conversation.CreatedBy.Valid {
+		res.CreatedBy = &conversation.CreatedBy.UUID
+	}
+	for _, member := range members {
+		memberRes := conversationMemberResponse{
+			UserId:   member.UserID,
+			JoinedAt: member.JoinedAt,
+		}
+		if member.LastReadAt.Valid {
+			memberRes.LastReadAt = &member.LastReadAt.Time
+		}
+		res.Members = append(res.Members, memberRes)
+	}
+	return res, nil
+}
+
+// directConversationKey identifies the one-to-one conversation between two
+// users regardless of who started it.
+func directConversationKey(a, b uuid.UUID) string {
+	first, second := a.String(), b.String()
+	if second < first {
+		first, second = second, first
+	}
+	return first + ":" + second
+}
+
+func toMessageResponse(message database.Message) messageResponse {
+	return messageResponse{
+		Id:             message.ID,
+		CreatedAt:      message.CreatedAt,
+		ConversationId: message.ConversationID,
+		SenderId:       message.SenderID,
+		Body:           message.Body,
+	}
+}
@@ -31,11 +31,13 @@ func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	if cfg.platform != "dev" {
 		writeErrorResponse(w, errors.New("forbidden"), http.StatusForbidden)
+		return
 	}
 
 	err := cfg.queries.DeleteAllUsers(r.Context())
 	if err != nil {
 		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
 	}
 
 	writeSuccessResponse(w, struct{}{}, http.StatusOK)
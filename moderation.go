@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/dberr"
+	"github.com/google/uuid"
+)
+
+const (
+	reportReasonSpam          = "spam"
+	reportReasonHarassment    = "harassment"
+	reportReasonHate          = "hate"
+	reportReasonViolence      = "violence"
+	reportReasonSexual        = "sexual"
+	reportReasonSelfHarm      = "self_harm"
+	reportReasonImpersonation = "impersonation"
+	reportReasonOther         = "other"
+)
+
+var reportReasons = map[string]bool{
+	reportReasonSpam:          true,
+	reportReasonHarassment:    true,
+	reportReasonHate:          true,
+	reportReasonViolence:      true,
+	reportReasonSexual:        true,
+	reportReasonSelfHarm:      true,
+	reportReasonImpersonation: true,
+	reportReasonOther:         true,
+}
+
+const (
+	moderationActionHideChirp   = "hide_chirp"
+	moderationActionSuspendUser = "suspend_user"
+	moderationActionDismiss     = "dismiss"
+)
+
+const (
+	maxReportDetails       = 1000
+	reportClaimLease       = 30 * time.Minute
+	defaultModerationLimit = 50
+	maxModerationLimit     = 100
+	defaultSuspensionDays  = 7
+	maxSuspensionDays      = 365
+)
+
+type reportResponse struct {
+	Id             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReporterId     uuid.UUID  `json:"reporter_id"`
+	ReportedUserId uuid.UUID  `json:"reported_user_id"`
+	ChirpId        *uuid.UUID `json:"chirp_id"`
+	ChirpBody      *string    `json:"chirp_body,omitempty"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	ClaimedBy      *uuid.UUID `json:"claimed_by"`
+	ClaimedAt      *time.Time `json:"claimed_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	Resolution     *string    `json:"resolution"`
+}
+
+type reportParameter struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	param, ok := decodeReportParameter(w, r)
+	if !ok {
+		return
+	}
+
+	idStr := r.PathValue("id")
+	chirpId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	// Only chirps the reporter can see may be reported, so reports can't be
+	// used to probe for hidden, private or followers-only chirps.
+	chirp, err := getVisibleChirp(r.Context(), cfg.queries, uuid.NullUUID{UUID: userId, Valid: true}, chirpId)
+	if err != nil {
+		if errors.Is(err, errChirpNotFound) {
+			writeErrorResponse(w, err, http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if chirp.UserID == userId {
+		writeErrorResponse(w, errors.New("cannot report your own chirp"), http.StatusBadRequest)
+		return
+	}
+
+	report, err := cfg.queries.CreateReport(r.Context(),
+		database.CreateReportParams{
+			ReporterID:     userId,
+			ReportedUserID: chirp.UserID,
+			ChirpID:        uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Reason:         param.Reason,
+			Details:        param.Details,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toReportResponse(report), http.StatusCreated)
+}
+
+func (cfg *apiConfig) handlerReportUser(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	param, ok := decodeReportParameter(w, r)
+	if !ok {
+		return
+	}
+
+	reportedId, ok := cfg.getRelationTarget(w, r, userId, "report")
+	if !ok {
+		return
+	}
+
+	report, err := cfg.queries.CreateReport(r.Context(),
+		database.CreateReportParams{
+			ReporterID:     userId,
+			ReportedUserID: reportedId,
+			Reason:         param.Reason,
+			Details:        param.Details,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toReportResponse(report), http.StatusCreated)
+}
+
+// handlerGetModerationQueue lists reports waiting for a moderator, oldest
+// first, including ones whose claim has lapsed.
+func (cfg *apiConfig) handlerGetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseModerationLimit(w, r)
+	if !ok {
+		return
+	}
+
+	reports, err := cfg.queries.GetModerationQueue(r.Context(),
+		database.GetModerationQueueParams{
+			ClaimExpiredBefore: time.Now().Add(-reportClaimLease),
+			RowLimit:           int32(limit),
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]reportResponse, 0, len(reports))
+	for _, row := range reports {
+		res := toReportResponse(database.Report{
+			ID:             row.ID,
+			CreatedAt:      row.CreatedAt,
+			ReporterID:     row.ReporterID,
+			ReportedUserID: row.ReportedUserID,
+			ChirpID:        row.ChirpID,
+			Reason:         row.Reason,
+			Details:        row.Details,
+			Status:         row.Status,
+			ClaimedBy:      row.ClaimedBy,
+			ClaimedAt:      row.ClaimedAt,
+			ResolvedAt:     row.ResolvedAt,
+			Resolution:     row.Resolution,
+		})
+		if row.ChirpBody.Valid {
+			res.ChirpBody = &row.ChirpBody.String
+		}
+		responses = append(responses, res)
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+// handlerClaimReport assigns a report to the calling moderator so two people
+// don't work the same case. A claim lapses after reportClaimLease.
+func (cfg *apiConfig) handlerClaimReport(w http.ResponseWriter, r *http.Request) {
+	moderatorId := userIDFromContext(r.Context())
+
+	idStr := r.PathValue("id")
+	reportId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	report, err := cfg.queries.ClaimReport(r.Context(),
+		database.ClaimReportParams{
+			ID:                 reportId,
+			ModeratorID:        uuid.NullUUID{UUID: moderatorId, Valid: true},
+			ClaimExpiredBefore: time.Now().Add(-reportClaimLease),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.writeReportConflict(w, r, reportId)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toReportResponse(report), http.StatusOK)
+}
+
+// handlerResolveReport closes a report the caller has claimed, applies the
+// chosen action and records it in the moderation audit log.
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Action      string `json:"action"`
+		Note        string `json:"note"`
+		SuspendDays int    `json:"suspend_days"`
+	}
+
+	moderatorId := userIDFromContext(r.Context())
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	switch param.Action {
+	case moderationActionHideChirp, moderationActionDismiss:
+	case moderationActionSuspendUser:
+		if param.SuspendDays == 0 {
+			param.SuspendDays = defaultSuspensionDays
+		}
+		if param.SuspendDays < 1 || param.SuspendDays > maxSuspensionDays {
+			writeErrorResponse(w, fmt.Errorf("suspend_days must be between 1 and %d", maxSuspensionDays), http.StatusBadRequest)
+			return
+		}
+	default:
+		writeErrorResponse(w, fmt.Errorf("invalid action %q", param.Action), http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	reportId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	report, err := qtx.ResolveReport(r.Context(),
+		database.ResolveReportParams{
+			ID:          reportId,
+			ModeratorID: uuid.NullUUID{UUID: moderatorId, Valid: true},
+			Resolution:  sql.NullString{String: param.Action, Valid: true},
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.writeReportConflict(w, r, reportId)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := applyModerationAction(r.Context(), qtx, report, param.Action, param.Note, param.SuspendDays); err != nil {
+		if errors.Is(err, errChirpNotFound) {
+			writeErrorResponse(w, err, http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	_, err = qtx.CreateModerationAction(r.Context(),
+		database.CreateModerationActionParams{
+			ModeratorID:   uuid.NullUUID{UUID: moderatorId, Valid: true},
+			ReportID:      uuid.NullUUID{UUID: report.ID, Valid: true},
+			Action:        param.Action,
+			TargetUserID:  uuid.NullUUID{UUID: report.ReportedUserID, Valid: true},
+			TargetChirpID: report.ChirpID,
+			Note:          param.Note,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toReportResponse(report), http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerGetModerationActions(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Id            uuid.UUID  `json:"id"`
+		CreatedAt     time.Time  `json:"created_at"`
+		ModeratorId   *uuid.UUID `json:"moderator_id"`
+		ReportId      *uuid.UUID `json:"report_id"`
+		Action        string     `json:"action"`
+		TargetUserId  *uuid.UUID `json:"target_user_id"`
+		TargetChirpId *uuid.UUID `json:"target_chirp_id"`
+		Note          string     `json:"note"`
+	}
+
+	limit, ok := parseModerationLimit(w, r)
+	if !ok {
+		return
+	}
+
+	actions, err := cfg.queries.GetModerationActions(r.Context(), int32(limit))
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]response, 0, len(actions))
+	for _, action := range actions {
+		res := response{
+			Id:        action.ID,
+			CreatedAt: action.CreatedAt,
+			Action:    action.Action,
+			Note:      action.Note,
+		}
+		if action.ModeratorID.Valid {
+			res.ModeratorId = &action.ModeratorID.UUID
+		}
+		if action.ReportID.Valid {
+			res.ReportId = &action.ReportID.UUID
+		}
+		if action.TargetUserID.Valid {
+			res.TargetUserId = &action.TargetUserID.UUID
+		}
+		if action.TargetChirpID.Valid {
+			res.TargetChirpId = &action.TargetChirpID.UUID
+		}
+		responses = append(responses, res)
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+func applyModerationAction(ctx context.Context, q *database.Queries, report database.Report, action, note string, suspendDays int) error {
+	switch action {
+	case moderationActionHideChirp:
+		// A reported chirp that has since been deleted leaves chirp_id null,
+		// the same as a report about a user, so both are reported as missing.
+		if !report.ChirpID.Valid {
+			return errChirpNotFound
+		}
+		_, err := q.HideChirp(ctx, report.ChirpID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errChirpNotFound
+		}
+		return err
+	case moderationActionSuspendUser:
+		user, err := q.GetUserById(ctx, report.ReportedUserID)
+		if err != nil {
+			return err
+		}
+		if user.Role != auth.RoleUser {
+			return errors.New("staff accounts must be demoted first")
+		}
+		reason := note
+		if reason == "" {
+			reason = "reported for " + strings.ReplaceAll(report.Reason, "_", " ")
+		}
+		return suspendAccount(ctx, q, report.ReportedUserID, time.Now().AddDate(0, 0, suspendDays), reason)
+	}
+	return nil
+}
+
+// writeReportConflict explains why a claim or resolve matched no rows: the
+// report doesn't exist, or it is resolved or held by someone else.
+func (cfg *apiConfig) writeReportConflict(w http.ResponseWriter, r *http.Request, reportId uuid.UUID) {
+	report, err := cfg.queries.GetReportById(r.Context(), reportId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("report not found"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	switch report.Status {
+	case "resolved":
+		writeErrorResponse(w, errors.New("report is already resolved"), http.StatusConflict)
+	case "claimed":
+		writeErrorResponse(w, errors.New("report is claimed by another moderator"), http.StatusConflict)
+	default:
+		writeErrorResponse(w, errors.New("report must be claimed before it is resolved"), http.StatusConflict)
+	}
+}
+
+func decodeReportParameter(w http.ResponseWriter, r *http.Request) (reportParameter, bool) {
+	decoder := json.NewDecoder(r.Body)
+	param := reportParameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return param, false
+	}
+
+	if !reportReasons[param.Reason] {
+		writeErrorResponse(w, fmt.Errorf("invalid reason %q", param.Reason), http.StatusBadRequest)
+		return param, false
+	}
+
+	if len(param.Details) > maxReportDetails {
+		writeErrorResponse(w, fmt.Errorf("details are limited to %d characters", maxReportDetails), http.StatusBadRequest)
+		return param, false
+	}
+
+	return param, true
+}
+
+func parseModerationLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	limit := defaultModerationLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxModerationLimit {
+			writeErrorResponse(w, fmt.Errorf("invalid limit %q", limitParam), http.StatusBadRequest)
+			return 0, false
+		}
+		limit = parsedLimit
+	}
+	return limit, true
+}
+
+func toReportResponse(report database.Report) reportResponse {
+	res := reportResponse{
+		Id:             report.ID,
+		CreatedAt:      report.CreatedAt,
+		ReporterId:     report.ReporterID,
+		ReportedUserId: report.ReportedUserID,
+		Reason:         report.Reason,
+		Details:        report.Details,
+		Status:         report.Status,
+	}
+	if report.ChirpID.Valid {
+		res.ChirpId = &report.ChirpID.UUID
+	}
+	if report.ClaimedBy.Valid {
+		res.ClaimedBy = &report.ClaimedBy.UUID
+	}
+	if report.ClaimedAt.Valid {
+		res.ClaimedAt = &report.ClaimedAt.Time
+	}
+	if report.ResolvedAt.Valid {
+		res.ResolvedAt = &report.ResolvedAt.Time
+	}
+	if report.Resolution.Valid {
+		res.Resolution = &report.Resolution.String
+	}
+	return res
+}
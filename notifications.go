@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	notificationTypeMention        = "mention"
+	notificationTypeFollow         = "follow"
+	notificationTypeFollowRequest  = "follow_request"
+	notificationTypeFollowApproved = "follow_approved"
+)
+
+var notificationTypes = []string{
+	notificationTypeMention,
+	notificationTypeFollow,
+	notificationTypeFollowRequest,
+	notificationTypeFollowApproved,
+}
+
+const (
+	notificationsChannel         = "notifications"
+	defaultNotificationLimit     = 20
+	maxNotificationLimit         = 100
+	maxMentionsPerChirp          = 10
+	notificationSubscriberBuffer = 16
+)
+
+// mentionPattern matches "@" followed by a handle, e.g. "@alice", which is
+// how chirps address other users. Emails are never looked up, so mentions
+// can't be used to test whether an address has an account. A match followed
+// by "@" or another handle character is part of something longer and is
+// skipped by mentionedHandles.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@.])@([A-Za-z0-9_]{3,20})`)
+
+type notificationResponse struct {
+	Id        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserId    uuid.UUID  `json:"user_id"`
+	ActorId   uuid.UUID  `json:"actor_id"`
+	Type      string     `json:"type"`
+	ChirpId   *uuid.UUID `json:"chirp_id"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Notifications []notificationResponse `json:"notifications"`
+		UnreadCount   int64                  `json:"unread_count"`
+		NextCursor    *uuid.UUID             `json:"next_cursor"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	limit := defaultNotificationLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxNotificationLimit {
+			writeErrorResponse(w, fmt.Errorf("invalid limit %q", limitParam), http.StatusBadRequest)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	var before uuid.NullUUID
+	if beforeParam := r.URL.Query().Get("before"); beforeParam != "" {
+		parsedBefore, err := uuid.Parse(beforeParam)
+		if err != nil {
+			writeErrorResponse(w, fmt.Errorf("invalid before %q: %w", beforeParam, err), http.StatusBadRequest)
+			return
+		}
+		before = uuid.NullUUID{UUID: parsedBefore, Valid: true}
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, err := cfg.queries.GetNotifications(r.Context(),
+		database.GetNotificationsParams{
+			UserID:     userId,
+			UnreadOnly: unreadOnly,
+			Before:     before,
+			RowLimit:   int32(limit),
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	unreadCount, err := cfg.queries.CountUnreadNotifications(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := response{
+		Notifications: make([]notificationResponse, 0, len(notifications)),
+		UnreadCount:   unreadCount,
+	}
+	for _, notification := range notifications {
+		res.Notifications = append(res.Notifications, toNotificationResponse(notification))
+	}
+	if len(notifications) == limit {
+		res.NextCursor = &notifications[len(notifications)-1].ID
+	}
+
+	writeSuccessResponse(w, res, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := cfg.queries.MarkNotificationRead(r.Context(),
+		database.MarkNotificationReadParams{
+			ID:     id,
+			UserID: userId,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		writeErrorResponse(w, errors.New("notification not found"), http.StatusNotFound)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := cfg.queries.MarkAllNotificationsRead(r.Context(), userId); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	res, err := cfg.notificationPreferences(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, res, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := map[string]bool{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	for notificationType := range param {
+		if !isNotificationType(notificationType) {
+			writeErrorResponse(w, fmt.Errorf("unknown notification type %q", notificationType), http.StatusBadRequest)
+			return
+		}
+	}
+
+	for notificationType, enabled := range param {
+		err := cfg.queries.UpsertNotificationPreference(r.Context(),
+			database.UpsertNotificationPreferenceParams{
+				UserID:  userId,
+				Type:    notificationType,
+				Enabled: enabled,
+			},
+		)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	res, err := cfg.notificationPreferences(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, res, http.StatusOK)
+}
+
+// notificationPreferences returns every notification type with its enabled
+// flag; types the user never changed are enabled.
+func (cfg *apiConfig) notificationPreferences(ctx context.Context, userId uuid.UUID) (map[string]bool, error) {
+	preferences, err := cfg.queries.GetNotificationPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]bool, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		res[notificationType] = true
+	}
+	for _, preference := range preferences {
+		res[preference.Type] = preference.Enabled
+	}
+	return res, nil
+}
+
+func isNotificationType(notificationType string) bool {
+	for _, known := range notificationTypes {
+		if known == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+func toNotificationResponse(notification database.Notification) notificationResponse {
+	res := notificationResponse{
+		Id:        notification.ID,
+		CreatedAt: notification.CreatedAt,
+		UserId:    notification.UserID,
+		ActorId:   notification.ActorID,
+		Type:      notification.Type,
+	}
+	if notification.ChirpID.Valid {
+		res.ChirpId = &notification.ChirpID.UUID
+	}
+	if notification.ReadAt.Valid {
+		res.ReadAt = &notification.ReadAt.Time
+	}
+	return res
+}
+
+// notifyMentions creates a mention notification for every distinct account
+// addressed in the chirp body, other than the author.
+func notifyMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for _, handle := range mentionedHandles(chirp.Body) {
+		user, err := q.GetUserByHandle(ctx, sql.NullString{String: handle, Valid: true})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return err
+		}
+		if user.ID == chirp.UserID {
+			continue
+		}
+
+		// Mentioning someone doesn't widen a chirp's audience.
+		visible, err := canViewChirp(ctx, q, uuid.NullUUID{UUID: user.ID, Valid: true}, chirp)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			continue
+		}
+
+		_, err = q.CreateNotification(ctx,
+			database.CreateNotificationParams{
+				UserID:  user.ID,
+				ActorID: chirp.UserID,
+				Type:    notificationTypeMention,
+				ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mentionedHandles returns the distinct handles mentioned in body,
+// lowercased, up to maxMentionsPerChirp.
+func mentionedHandles(body string) []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, loc := range mentionPattern.FindAllStringSubmatchIndex(body, -1) {
+		end := loc[3]
+		if end < len(body) && (body[end] == '@' || isHandleChar(body[end])) {
+			continue
+		}
+
+		handle := strings.ToLower(body[loc[2]:end])
+		if seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+		if len(handles) == maxMentionsPerChirp {
+			break
+		}
+	}
+	return handles
+}
+
+func isHandleChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+// notificationHub delivers newly created notifications to the recipient's
+// live gateway connections. Rows are announced by a trigger on the
+// notifications table, so inserts from any server instance reach every hub.
+type notificationHub struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan notificationResponse]struct{}
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{
+		subscribers: make(map[uuid.UUID]map[chan notificationResponse]struct{}),
+	}
+}
+
+func (h *notificationHub) run(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Error listening for notifications: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(notificationsChannel); err != nil {
+		log.Printf("Error listening on %s: %s", notificationsChannel, err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				continue
+			}
+			var notification notificationResponse
+			if err := json.Unmarshal([]byte(n.Extra), &notification); err != nil {
+				log.Printf("Error decoding notification: %s", err)
+				continue
+			}
+			h.broadcast(notification)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+func (h *notificationHub) broadcast(notification notificationResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[notification.UserId] {
+		select {
+		case ch <- notification:
+		default:
+			h.remove(notification.UserId, ch)
+		}
+	}
+}
+
+func (h *notificationHub) subscribe(userId uuid.UUID) chan notificationResponse {
+	ch := make(chan notificationResponse, notificationSubscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[userId] == nil {
+		h.subscribers[userId] = make(map[chan notificationResponse]struct{})
+	}
+	h.subscribers[userId][ch] = struct{}{}
+
+	return ch
+}
+
+func (h *notificationHub) unsubscribe(userId uuid.UUID, ch chan notificationResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(userId, ch)
+}
+
+// remove must be called with h.mu held.
+func (h *notificationHub) remove(userId uuid.UUID, ch chan notificationResponse) {
+	if _, ok := h.subscribers[userId][ch]; !ok {
+		return
+	}
+	delete(h.subscribers[userId], ch)
+	if len(h.subscribers[userId]) == 0 {
+		delete(h.subscribers, userId)
+	}
+	close(ch)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPinnedChirps          = 3
+	maxPinnedChirpsChirpyRed = 10
+)
+
+// handlerPinChirp pins one of the caller's chirps to their profile. Pinning
+// a chirp that is already pinned is a no-op.
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	chirp, ok := cfg.getOwnChirp(w, r, qtx)
+	if !ok {
+		return
+	}
+
+	// Locking the user serialises concurrent pins against the limit.
+	user, err := qtx.LockUserById(r.Context(), chirp.UserID)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	limit := maxPinnedChirps
+	if user.IsChirpyRed {
+		limit = maxPinnedChirpsChirpyRed
+	}
+
+	count, err := qtx.CountPinnedChirps(r.Context(), user.ID)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pinned, err := qtx.PinChirp(r.Context(),
+		database.PinChirpParams{
+			UserID:  user.ID,
+			ChirpID: chirp.ID,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	// Re-pinning inserts nothing, so it is allowed even at the limit.
+	if pinned > 0 && count >= int64(limit) {
+		writeErrorResponse(w, fmt.Errorf("you can pin at most %d chirps", limit), http.StatusConflict)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	chirp, ok := cfg.getOwnChirp(w, r, cfg.queries)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.queries.UnpinChirp(r.Context(),
+		database.UnpinChirpParams{
+			UserID:  chirp.UserID,
+			ChirpID: chirp.ID,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerGetPinnedChirps lists a user's pinned chirps that the caller can
+// see, most recently pinned first.
+func (cfg *apiConfig) handlerGetPinnedChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.getOptionalViewer(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	userId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	chirps, err := cfg.queries.GetPinnedChirpsByUserId(r.Context(),
+		database.GetPinnedChirpsByUserIdParams{
+			UserID:   userId,
+			ViewerID: viewerID,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]chirpResponse, 0, len(chirps))
+	for _, chirp := range chirps {
+		res := toChirpResponse(chirp)
+		res.Pinned = true
+		responses = append(responses, res)
+	}
+
+	if err := attachChirpDetails(r.Context(), cfg.queries, viewerID, responses); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/subscription"
+	"github.com/Sanghun1Adam1Park/chirp/internal/webhook"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookEventStatusProcessed = "processed"
+	webhookEventStatusIgnored   = "ignored"
+	webhookEventStatusFailed    = "failed"
+)
+
+const polkaSignatureTolerance = 5 * time.Minute
+
+type polkaEventData struct {
+	UserId     uuid.UUID  `json:"user_id"`
+	PeriodEnd  *time.Time `json:"period_end"`
+	GraceUntil *time.Time `json:"grace_until"`
+}
+
+func (cfg *apiConfig) handlerPolkaWebhook(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Id    string         `json:"id"`
+		Event string         `json:"event"`
+		Data  polkaEventData `json:"data"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.polka_key)) != 1 {
+		writeErrorResponse(w, errors.New("invalid polka key"), http.StatusUnauthorized)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = webhook.Verify(r.Header.Get("Polka-Signature"), data, cfg.polka_key, polkaSignatureTolerance, time.Now())
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	var param parameter
+	if err := json.Unmarshal(data, &param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if param.Id == "" {
+		writeErrorResponse(w, errors.New("missing event id"), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cfg.queries.RecordWebhookEvent(r.Context(),
+		database.RecordWebhookEventParams{
+			ID:      param.Id,
+			Event:   param.Event,
+			Payload: data,
+		},
+	); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	err = cfg.processPolkaEvent(r.Context(), param.Id, param.Event, param.Data)
+	if err != nil {
+		markErr := cfg.queries.MarkWebhookEvent(r.Context(),
+			database.MarkWebhookEventParams{
+				ID:     param.Id,
+				Status: webhookEventStatusFailed,
+				Error:  sql.NullString{String: err.Error(), Valid: true},
+			},
+		)
+		if markErr != nil {
+			log.Printf("Error recording failed webhook event %s: %s", param.Id, markErr)
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("user not found"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, struct{}{}, http.StatusNoContent)
+}
+
+// processPolkaEvent applies a recorded event exactly once. The event row is
+// locked for the duration of the transaction so that concurrent redeliveries
+// wait for the first one and then see it as already processed.
+func (cfg *apiConfig) processPolkaEvent(ctx context.Context, id, event string, data polkaEventData) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	recorded, err := qtx.LockWebhookEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+	if recorded.Status == webhookEventStatusProcessed || recorded.Status == webhookEventStatusIgnored {
+		return tx.Commit()
+	}
+
+	status := webhookEventStatusProcessed
+	switch event {
+	case subscription.EventUpgraded, subscription.EventDowngraded, subscription.EventRenewed,
+		subscription.EventPaymentFailed, subscription.EventGracePeriod:
+		if data.UserId == uuid.Nil {
+			return errors.New("missing user_id")
+		}
+		err := applyPolkaEvent(ctx, qtx, event, data)
+		if errors.Is(err, subscription.ErrNotApplicable) {
+			status = webhookEventStatusIgnored
+		} else if err != nil {
+			return err
+		}
+	default:
+		status = webhookEventStatusIgnored
+	}
+
+	err = qtx.MarkWebhookEvent(ctx,
+		database.MarkWebhookEventParams{
+			ID:     id,
+			Status: status,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applyPolkaEvent moves the user's subscription to the state implied by event
+// and keeps users.is_chirpy_red in sync with it. Events that don't apply to
+// the current state return subscription.ErrNotApplicable and change nothing.
+func applyPolkaEvent(ctx context.Context, qtx *database.Queries, event string, data polkaEventData) error {
+	var current *subscription.State
+	row, err := qtx.GetSubscriptionByUserId(ctx, data.UserId)
+	if err == nil {
+		current = &subscription.State{
+			Status:     row.Status,
+			PeriodEnd:  row.PeriodEnd,
+			GraceUntil: row.GraceUntil.Time,
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	state, err := subscription.Next(current,
+		subscription.Event{
+			Name:       event,
+			PeriodEnd:  data.PeriodEnd,
+			GraceUntil: data.GraceUntil,
+		},
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	next := database.UpsertSubscriptionParams{
+		UserID:     data.UserId,
+		Status:     state.Status,
+		PeriodEnd:  state.PeriodEnd,
+		GraceUntil: sql.NullTime{Time: state.GraceUntil, Valid: !state.GraceUntil.IsZero()},
+	}
+
+	if subscription.GrantsChirpyRed(next.Status) {
+		_, err = qtx.UpgradeToChirpyRed(ctx, data.UserId)
+	} else {
+		_, err = qtx.DowngradeFromChirpyRed(ctx, data.UserId)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := qtx.UpsertSubscription(ctx, next); err != nil {
+		return err
+	}
+
+	if event != subscription.EventUpgraded {
+		return nil
+	}
+
+	upgraded := struct {
+		UserId      uuid.UUID `json:"user_id"`
+		IsChirpyRed bool      `json:"is_chirpy_red"`
+		PeriodEnd   time.Time `json:"period_end"`
+	}{
+		UserId:      data.UserId,
+		IsChirpyRed: true,
+		PeriodEnd:   next.PeriodEnd,
+	}
+	return enqueueWebhookEvent(ctx, qtx, webhookEventUserUpgraded, uuid.NullUUID{UUID: data.UserId, Valid: true}, upgraded)
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/chirptext"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// chirpEventPollUpdated tells stream clients a poll has a new vote. It
+// carries no counts, which may still be hidden from some viewers; clients
+// refetch the chirp to see what they're allowed to.
+const chirpEventPollUpdated = "poll.updated"
+
+type pollParameter struct {
+	Options   []string  `json:"options" validate:"required,min=2,max=4"`
+	ExpiresAt time.Time `json:"expires_at" validate:"required"`
+}
+
+// pollResponse is a poll as seen by one viewer. Votes and TotalVotes are
+// left out until the viewer has voted or the poll has closed.
+type pollResponse struct {
+	Id            uuid.UUID            `json:"id"`
+	ExpiresAt     time.Time            `json:"expires_at"`
+	Closed        bool                 `json:"closed"`
+	Options       []pollOptionResponse `json:"options"`
+	TotalVotes    *int64               `json:"total_votes,omitempty"`
+	VotedOptionId *uuid.UUID           `json:"voted_option_id,omitempty"`
+}
+
+type pollOptionResponse struct {
+	Id    uuid.UUID `json:"id"`
+	Text  string    `json:"text"`
+	Votes *int64    `json:"votes,omitempty"`
+}
+
+// handlerVotePoll casts the caller's vote in the poll on a chirp they can
+// see. Each user votes once and votes can't be changed. The response is the
+// poll with its current tallies.
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		OptionId uuid.UUID `json:"option_id"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	chirpId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: userId, Valid: true}
+	chirp, err := getVisibleChirp(r.Context(), cfg.queries, viewerID, chirpId)
+	if err != nil {
+		if errors.Is(err, errChirpNotFound) {
+			writeErrorResponse(w, err, http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	poll, err := cfg.queries.GetPollByChirpId(r.Context(), chirp.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, errors.New("chirp has no poll"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if !time.Now().Before(poll.ExpiresAt) {
+		writeErrorResponse(w, errors.New("poll is closed"), http.StatusConflict)
+		return
+	}
+
+	option, err := cfg.queries.GetPollOptionById(r.Context(), param.OptionId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if err != nil || option.PollID != poll.ID {
+		writeErrorResponse(w, errors.New("option is not part of this poll"), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	voted, err := qtx.CreatePollVote(r.Context(),
+		database.CreatePollVoteParams{
+			PollID:   poll.ID,
+			UserID:   userId,
+			OptionID: option.ID,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	if voted == 0 {
+		writeErrorResponse(w, errors.New("already voted in this poll"), http.StatusConflict)
+		return
+	}
+
+	event := struct {
+		ChirpId uuid.UUID `json:"chirp_id"`
+		PollId  uuid.UUID `json:"poll_id"`
+	}{ChirpId: chirp.ID, PollId: poll.ID}
+	if _, err := recordChirpEvent(r.Context(), qtx, chirpEventPollUpdated, chirp, event); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	polls, err := getPollResponses(r.Context(), cfg.queries, viewerID, []uuid.UUID{chirp.ID})
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, polls[chirp.ID], http.StatusOK)
+}
+
+// parsePoll validates a requested poll and normalizes its options the way
+// chirp bodies are, so lengths are counted the same way too.
+func parsePoll(param pollParameter) (pollParameter, error) {
+	if len(param.Options) < minPollOptions || len(param.Options) > maxPollOptions {
+		return pollParameter{}, fmt.Errorf("polls need %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	options := make([]string, 0, len(param.Options))
+	seen := make(map[string]bool, len(param.Options))
+	for _, option := range param.Options {
+		option = strings.TrimSpace(chirptext.Normalize(option))
+		if option == "" {
+			return pollParameter{}, errors.New("poll options can't be empty")
+		}
+		if chirptext.Length(option) > maxPollOptionLength {
+			return pollParameter{}, fmt.Errorf("poll options must be at most %d characters", maxPollOptionLength)
+		}
+		if seen[option] {
+			return pollParameter{}, fmt.Errorf("duplicate poll option %q", option)
+		}
+		seen[option] = true
+		options = append(options, option)
+	}
+
+	now := time.Now()
+	if param.ExpiresAt.Before(now.Add(minPollDuration)) {
+		return pollParameter{}, errors.New("polls must run for at least 5 minutes")
+	}
+	if param.ExpiresAt.After(now.Add(maxPollDuration)) {
+		return pollParameter{}, errors.New("polls must close within 7 days")
+	}
+
+	return pollParameter{Options: options, ExpiresAt: param.ExpiresAt}, nil
+}
+
+// createPoll attaches a poll to a new chirp. The caller owns the
+// transaction.
+func createPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, param pollParameter) error {
+	poll, err := q.CreatePoll(ctx,
+		database.CreatePollParams{
+			ChirpID:   chirpID,
+			ExpiresAt: param.ExpiresAt,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, option := range param.Options {
+		_, err := q.CreatePollOption(ctx,
+			database.CreatePollOptionParams{
+				PollID:   poll.ID,
+				Position: int32(i),
+				Text:     option,
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attachPolls fills in the poll on each chirp that has one, as seen by
+// viewerID. Tallies are counted when the chirps are read.
+func attachPolls(ctx context.Context, q *database.Queries, viewerID uuid.NullUUID, chirps []chirpResponse) error {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.Id)
+	}
+
+	polls, err := getPollResponses(ctx, q, viewerID, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range chirps {
+		if poll, ok := polls[chirps[i].Id]; ok {
+			chirps[i].Poll = &poll
+		}
+	}
+	return nil
+}
+
+// getPollResponses loads the polls on chirpIDs, keyed by chirp id.
+func getPollResponses(ctx context.Context, q *database.Queries, viewerID uuid.NullUUID, chirpIDs []uuid.UUID) (map[uuid.UUID]pollResponse, error) {
+	res := make(map[uuid.UUID]pollResponse)
+	if len(chirpIDs) == 0 {
+		return res, nil
+	}
+
+	polls, err := q.GetPollsByChirpIds(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(polls) == 0 {
+		return res, nil
+	}
+
+	pollIDs := make([]uuid.UUID, 0, len(polls))
+	for _, poll := range polls {
+		pollIDs = append(pollIDs, poll.ID)
+	}
+
+	tallies, err := q.GetPollOptionTallies(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[uuid.UUID][]database.GetPollOptionTalliesRow)
+	for _, tally := range tallies {
+		options[tally.PollID] = append(options[tally.PollID], tally)
+	}
+
+	votes := make(map[uuid.UUID]uuid.UUID)
+	if viewerID.Valid {
+		rows, err := q.GetPollVotesByUserId(ctx,
+			database.GetPollVotesByUserIdParams{
+				UserID:  viewerID.UUID,
+				PollIds: pollIDs,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range rows {
+			votes[vote.PollID] = vote.OptionID
+		}
+	}
+
+	now := time.Now()
+	for _, poll := range polls {
+		pollRes := pollResponse{
+			Id:        poll.ID,
+			ExpiresAt: poll.ExpiresAt,
+			Closed:    !now.Before(poll.ExpiresAt),
+			Options:   make([]pollOptionResponse, 0, len(options[poll.ID])),
+		}
+
+		optionID, voted := votes[poll.ID]
+		if voted {
+			pollRes.VotedOptionId = &optionID
+		}
+		showResults := voted || pollRes.Closed
+
+		var total int64
+		for _, option := range options[poll.ID] {
+			optionRes := pollOptionResponse{
+				Id:   option.ID,
+				Text: option.Text,
+			}
+			if showResults {
+				count := option.Votes
+				optionRes.Votes = &count
+			}
+			total += option.Votes
+			pollRes.Options = append(pollRes.Options, optionRes)
+		}
+		if showResults {
+			pollRes.TotalVotes = &total
+		}
+
+		res[poll.ChirpID] = pollRes
+	}
+
+	return res, nil
+}
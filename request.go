@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/jsonerr"
+	"github.com/Sanghun1Adam1Park/chirp/internal/validate"
+)
+
+// maxRequestBodySize caps JSON request bodies. The largest legitimate body,
+// a chirp with a poll, is well under a kilobyte.
+const maxRequestBodySize = 64 << 10
+
+// decodeJSON reads a single JSON object from r's body into dst, then checks
+// dst's validate tags. Bodies must be sent as application/json, stay under
+// maxRequestBodySize, and hold nothing but the fields dst knows about.
+// Every error is an *apiError ready for writeErrorResponse.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &apiError{
+			Status: http.StatusUnsupportedMediaType,
+			Code:   "unsupported_media_type",
+			Detail: "Content-Type must be application/json",
+		}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		if apiErr, ok := decodeError(err); ok {
+			return apiErr
+		}
+		return &apiError{
+			Status: http.StatusBadRequest,
+			Code:   "malformed_json",
+			Detail: "request body could not be decoded",
+		}
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apiErr, _ := decodeError(err)
+			return apiErr
+		}
+		return &apiError{
+			Status: http.StatusBadRequest,
+			Code:   "malformed_json",
+			Detail: "request body must contain a single JSON object",
+		}
+	}
+
+	if errs := validate.Struct(dst); len(errs) > 0 {
+		fieldErrs := make([]fieldError, 0, len(errs))
+		for _, e := range errs {
+			fieldErrs = append(fieldErrs, fieldError{Field: e.Field, Code: e.Code, Message: e.Message})
+		}
+		return newValidationError(fieldErrs...)
+	}
+	return nil
+}
+
+// decodeError describes a failed Decode in terms a client can act on,
+// without echoing Go type names. It reports false for errors that don't come
+// from decoding a request body. writeErrorResponse also uses it, so handlers
+// that still decode by hand don't leak encoding/json's messages either.
+func decodeError(err error) (*apiError, bool) {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		maxBytesErr  *http.MaxBytesError
+		timeErr      *time.ParseError
+		malformedErr = &apiError{Status: http.StatusBadRequest, Code: "malformed_json"}
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &apiError{
+			Status: http.StatusRequestEntityTooLarge,
+			Code:   "payload_too_large",
+			Detail: fmt.Sprintf("request body must be at most %d bytes", maxBytesErr.Limit),
+		}, true
+	case errors.Is(err, io.EOF):
+		malformedErr.Detail = "request body is empty"
+	case errors.As(err, &syntaxErr):
+		malformedErr.Detail = fmt.Sprintf("request body is not valid JSON (at byte %d)", syntaxErr.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		malformedErr.Detail = "request body is not valid JSON"
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			malformedErr.Detail = "request body must be a JSON object"
+			break
+		}
+		return newValidationError(fieldError{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("%s must be a JSON %s", typeErr.Field, jsonKind(typeErr.Type.String())),
+		}), true
+	case errors.As(err, &timeErr):
+		malformedErr.Detail = "timestamps must be RFC 3339 strings"
+	default:
+		field, ok := jsonerr.UnknownField(err)
+		if !ok {
+			return nil, false
+		}
+		return newValidationError(fieldError{
+			Field:   field,
+			Code:    "unknown_field",
+			Message: fmt.Sprintf("unknown field %s", field),
+		}), true
+	}
+	return malformedErr, true
+}
+
+// jsonKind names the JSON type a Go type is decoded from.
+func jsonKind(goType string) string {
+	switch {
+	case goType == "string", goType == "time.Time", goType == "uuid.UUID", goType == "*time.Time":
+		return "string"
+	case goType == "bool":
+		return "boolean"
+	case strings.HasPrefix(goType, "[]"):
+		return "array"
+	case strings.HasPrefix(goType, "int"), strings.HasPrefix(goType, "uint"), strings.HasPrefix(goType, "float"):
+		return "number"
+	}
+	return "object"
+}
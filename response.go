@@ -2,31 +2,276 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/dberr"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+// nextCursorHeader carries the cursor for the next page of endpoints whose
+// body is a plain array.
+const nextCursorHeader = "X-Next-Cursor"
+
+// Constraints Postgres names for users.email and users.handle.
+const (
+	uniqueEmailConstraint  = "users_email_key"
+	uniqueHandleConstraint = "users_handle_key"
 )
 
-type errorResponse struct {
-	Error string `json:"error"`
+// problem is an RFC 7807 problem details body. Code is a stable,
+// machine-readable error code; Errors lists field-level problems.
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Code      string       `json:"code"`
+	RequestId string       `json:"request_id,omitempty"`
+	Errors    []fieldError `json:"errors,omitempty"`
 }
 
-func writeErrorResponse(w http.ResponseWriter, err error, statusCode int) {
-	log.Printf("Error decoding parameters: %s", err)
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
 
-	response := errorResponse{
-		Error: err.Error(),
+// apiError is an error meant for clients. Handlers return one when the
+// status alone doesn't say enough; Status, when set, overrides the status
+// passed to writeErrorResponse.
+type apiError struct {
+	Status int
+	Code   string
+	Detail string
+	Errors []fieldError
+}
+
+func (e *apiError) Error() string {
+	return e.Detail
+}
+
+var errInvalidCredentials = &apiError{
+	Status: http.StatusUnauthorized,
+	Code:   "invalid_credentials",
+	Detail: "incorrect email or password",
+}
+
+// newValidationError reports every field-level problem with a request, so
+// clients can show them all at once.
+func newValidationError(errs ...fieldError) *apiError {
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+	return &apiError{
+		Status: http.StatusBadRequest,
+		Code:   "validation_failed",
+		Detail: strings.Join(messages, "; "),
+		Errors: errs,
 	}
-	data, err := json.Marshal(response)
+}
+
+// middlewareRequestID tags every request with an id, taken from the
+// client's X-Request-Id header when it is reasonable and generated
+// otherwise, and echoes it on the response so errors can be traced in logs.
+func middlewareRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeErrorResponse writes err as application/problem+json. Errors that
+// weren't written for clients are mapped to a meaningful client error where
+// possible, and server errors never expose their details.
+func writeErrorResponse(w http.ResponseWriter, err error, statusCode int) {
+	requestID := w.Header().Get(requestIDHeader)
+	log.Printf("Request %s failed with status %d: %s", requestID, statusCode, err)
+
+	res := toProblem(err, statusCode)
+	res.RequestId = requestID
+
+	data, err := json.Marshal(res)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(res.Status)
 	w.Write(data)
 }
 
+func toProblem(err error, statusCode int) problem {
+	res := problem{
+		Type:   "about:blank",
+		Status: statusCode,
+		Detail: err.Error(),
+	}
+
+	// Handlers report bad request bodies as client errors; the check keeps
+	// an io.EOF from, say, a mail server from being mistaken for one.
+	var apiErr *apiError
+	if decodeErr, ok := decodeError(err); ok && statusCode < 500 {
+		apiErr = decodeErr
+	}
+	if apiErr != nil || errors.As(err, &apiErr) {
+		if apiErr.Status != 0 {
+			res.Status = apiErr.Status
+		}
+		res.Code = apiErr.Code
+		res.Detail = apiErr.Detail
+		res.Errors = apiErr.Errors
+	} else if mapped := mapInternalError(err); mapped != nil {
+		// A status the handler chose deliberately stands. The mapping only
+		// refines plain 500s and turns internal failures reported as client
+		// errors back into server errors.
+		switch {
+		case statusCode == http.StatusInternalServerError || mapped.Status == statusCode,
+			statusCode < 500 && mapped.Status >= 500:
+			res.Status = mapped.Status
+			res.Code = mapped.Code
+			res.Detail = mapped.Detail
+			res.Errors = mapped.Errors
+		default:
+			res.Detail = http.StatusText(statusCode)
+		}
+	}
+
+	if res.Status >= 500 {
+		res.Detail = "An internal error occurred."
+		res.Errors = nil
+		res.Code = "internal_error"
+		if res.Status == http.StatusServiceUnavailable {
+			res.Detail = "The service is temporarily unavailable. Try again later."
+			res.Code = "service_unavailable"
+		}
+	}
+	if res.Code == "" {
+		res.Code = defaultErrorCode(res.Status)
+	}
+	res.Title = http.StatusText(res.Status)
+	return res
+}
+
+// mapInternalError turns database and password-hashing errors into client
+// errors. Database errors it can't classify are reported as server errors so
+// their text doesn't reach clients.
+func mapInternalError(err error) *apiError {
+	switch {
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return errInvalidCredentials
+	case errors.Is(err, bcrypt.ErrPasswordTooLong):
+		return newValidationError(fieldError{Field: "password", Code: "too_long", Message: "password must be at most 72 bytes"})
+	}
+
+	kind := dberr.Classify(err)
+	switch kind {
+	case dberr.NotFound:
+		return &apiError{
+			Status: kind.Status(),
+			Code:   "not_found",
+			Detail: "resource not found",
+		}
+	case dberr.UniqueViolation:
+		switch dberr.Constraint(err) {
+		case uniqueEmailConstraint:
+			return &apiError{
+				Status: kind.Status(),
+				Code:   "email_taken",
+				Detail: "email is already in use",
+				Errors: []fieldError{{Field: "email", Code: "taken", Message: "email is already in use"}},
+			}
+		case uniqueHandleConstraint:
+			return &apiError{
+				Status: kind.Status(),
+				Code:   "handle_taken",
+				Detail: "handle is already in use",
+				Errors: []fieldError{{Field: "handle", Code: "taken", Message: "handle is already in use"}},
+			}
+		}
+		return &apiError{
+			Status: kind.Status(),
+			Code:   "already_exists",
+			Detail: "resource already exists",
+		}
+	case dberr.ForeignKeyViolation:
+		return &apiError{
+			Status: kind.Status(),
+			Code:   "reference_not_found",
+			Detail: "a referenced resource does not exist",
+		}
+	case dberr.CheckViolation:
+		return &apiError{
+			Status: kind.Status(),
+			Code:   "invalid_data",
+			Detail: "request contains invalid data",
+		}
+	case dberr.SerializationFailure:
+		return &apiError{
+			Status: kind.Status(),
+			Code:   "concurrent_update",
+			Detail: "request conflicted with a concurrent update; retry it",
+		}
+	case dberr.Connection, dberr.Canceled:
+		return &apiError{Status: kind.Status()}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return &apiError{Status: http.StatusInternalServerError}
+	}
+	return nil
+}
+
+func defaultErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusGone:
+		return "gone"
+	case http.StatusRequestEntityTooLarge:
+		return "payload_too_large"
+	case http.StatusUnsupportedMediaType:
+		return "unsupported_media_type"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	default:
+		return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+}
+
 func writeSuccessResponse(w http.ResponseWriter, jsonMap interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
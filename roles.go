@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/dberr"
+	"github.com/google/uuid"
+)
+
+type contextKey string
+
+const userIDContextKey contextKey = "user_id"
+
+// middlewareRequireRole only lets requests through whose access token was
+// issued to a user holding at least the required role. The authenticated
+// user id is stored on the request context for userIDFromContext.
+//
+// The token's role claim is only a first filter. The role is re-read from
+// the database on every request, so a demotion takes effect immediately
+// rather than when the access token expires.
+func (cfg *apiConfig) middlewareRequireRole(required string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		userId, err := auth.ValidateJWT(token, cfg.secret)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		role, err := auth.GetJWTRole(token, cfg.secret)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		if !auth.HasRole(role, required) {
+			writeErrorResponse(w, errors.New("forbidden"), http.StatusForbidden)
+			return
+		}
+
+		user, err := cfg.queries.GetUserById(r.Context(), userId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeErrorResponse(w, errors.New("account not found"), http.StatusUnauthorized)
+				return
+			}
+			writeErrorResponse(w, err, dberr.Status(err))
+			return
+		}
+		if !auth.HasRole(user.Role, required) {
+			writeErrorResponse(w, errors.New("forbidden"), http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDContextKey, userId)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// userIDFromContext returns the user authenticated by middlewareRequireRole.
+func userIDFromContext(ctx context.Context) uuid.UUID {
+	userId, _ := ctx.Value(userIDContextKey).(uuid.UUID)
+	return userId
+}
+
+func (cfg *apiConfig) handlerUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Role string `json:"role"`
+	}
+	type response struct {
+		Id   uuid.UUID `json:"id"`
+		Role string    `json:"role"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if !auth.ValidRole(param.Role) {
+		writeErrorResponse(w, fmt.Errorf("invalid role %q", param.Role), http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	targetId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	// Keeps an admin from demoting themselves and leaving nobody in charge.
+	if targetId == userIDFromContext(r.Context()) {
+		writeErrorResponse(w, errors.New("cannot change your own role"), http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.UpdateUserRole(r.Context(),
+		database.UpdateUserRoleParams{
+			ID:   targetId,
+			Role: param.Role,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("user not found"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, response{Id: user.ID, Role: user.Role}, http.StatusOK)
+}
+
+// grantAdmin promotes the account registered under email to admin. It backs
+// the grant-admin command, which is how the first admin gets created.
+func grantAdmin(ctx context.Context, queries *database.Queries, email string) error {
+	user, err := queries.UpdateUserRoleByEmail(ctx,
+		database.UpdateUserRoleByEmailParams{
+			Email: email,
+			Role:  auth.RoleAdmin,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no user with email %q", email)
+		}
+		return err
+	}
+
+	fmt.Printf("Granted admin to %s (%s)\n", user.Email, user.ID)
+	return nil
+}
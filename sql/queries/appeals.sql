@@ -0,0 +1,32 @@
+-- name: CreateAppeal :one
+INSERT INTO appeals (id, created_at, user_id, body)
+VALUES (gen_random_uuid(), NOW(), $1, $2)
+RETURNING *;
+
+-- name: GetOpenAppealByUserId :one
+SELECT *
+FROM appeals
+WHERE user_id = $1
+    AND status = 'open';
+
+-- name: GetOpenAppeals :many
+SELECT *
+FROM appeals
+WHERE status = 'open'
+ORDER BY created_at ASC
+LIMIT $1;
+
+-- name: ResolveAppeal :one
+UPDATE appeals
+SET status = $2,
+    reviewed_by = $3,
+    reviewed_at = NOW(),
+    response = $4
+WHERE id = $1
+    AND status = 'open'
+RETURNING *;
+
+-- name: GetAppealById :one
+SELECT *
+FROM appeals
+WHERE id = $1;
@@ -0,0 +1,47 @@
+-- name: BlockUser :execrows
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1
+    AND blocked_id = $2;
+
+-- name: GetBlocksByBlockerId :many
+SELECT *
+FROM blocks
+WHERE blocker_id = $1
+ORDER BY created_at DESC;
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+    SELECT 1
+    FROM blocks
+    WHERE (blocker_id = sqlc.arg(user_a) AND blocked_id = sqlc.arg(user_b))
+        OR (blocker_id = sqlc.arg(user_b) AND blocked_id = sqlc.arg(user_a))
+);
+
+-- name: IsBlockedByConversationMember :one
+SELECT EXISTS (
+    SELECT 1
+    FROM conversation_members cm
+    JOIN blocks b ON (b.blocker_id = cm.user_id AND b.blocked_id = sqlc.arg(user_id))
+        OR (b.blocker_id = sqlc.arg(user_id) AND b.blocked_id = cm.user_id)
+    WHERE cm.conversation_id = sqlc.arg(conversation_id)
+);
+
+-- name: GetBlockRelatedUserIds :many
+-- Everyone the user has blocked or been blocked by.
+SELECT blocked_id AS user_id
+FROM blocks
+WHERE blocker_id = $1
+UNION
+SELECT blocker_id AS user_id
+FROM blocks
+WHERE blocked_id = $1;
+
+-- name: RemoveFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg(user_a) AND followee_id = sqlc.arg(user_b))
+    OR (follower_id = sqlc.arg(user_b) AND followee_id = sqlc.arg(user_a));
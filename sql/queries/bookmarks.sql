@@ -0,0 +1,62 @@
+-- name: CreateBookmark :execrows
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1
+    AND chirp_id = $2;
+
+-- name: GetBookmarkedChirps :many
+-- Newest bookmark first. Chirps the owner can no longer see (hidden,
+-- blocked, or outside their audience) are left out but keep their bookmark.
+-- The cursor carries the sort key itself, so removing the bookmark it was
+-- taken from doesn't end pagination.
+SELECT c.*, b.created_at AS bookmarked_at
+FROM bookmarks b
+JOIN chirps c ON c.id = b.chirp_id
+WHERE b.user_id = sqlc.narg(viewer_id)
+    AND (c.hidden_at IS NULL OR c.user_id = sqlc.narg(viewer_id))
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks bl
+            WHERE (bl.blocker_id = sqlc.narg(viewer_id) AND bl.blocked_id = c.user_id)
+                OR (bl.blocker_id = c.user_id AND bl.blocked_id = sqlc.narg(viewer_id))
+        )
+    )
+    AND (
+        c.user_id = sqlc.narg(viewer_id)
+        OR (
+            c.visibility IN ('public', 'unlisted')
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = c.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            c.visibility IN ('public', 'unlisted', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.narg(viewer_id)
+                    AND f.followee_id = c.user_id
+            )
+        )
+    )
+    AND (
+        sqlc.narg(before_time)::timestamptz IS NULL
+        OR (b.created_at, b.chirp_id) < (sqlc.narg(before_time)::timestamptz, sqlc.narg(before_id)::uuid)
+    )
+ORDER BY b.created_at DESC, b.chirp_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetBookmarksByUserId :many
+SELECT *
+FROM bookmarks
+WHERE user_id = $1
+ORDER BY created_at ASC;
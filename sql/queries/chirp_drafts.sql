@@ -0,0 +1,62 @@
+-- name: CreateDraft :one
+INSERT INTO chirp_drafts (id, created_at, updated_at, user_id, body, scheduled_at, visibility)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetDraftsByUserId :many
+-- Scheduled drafts first, soonest first, then unscheduled ones by last edit.
+SELECT *
+FROM chirp_drafts
+WHERE user_id = sqlc.arg(user_id)
+    AND (NOT sqlc.arg(scheduled_only)::bool OR scheduled_at IS NOT NULL)
+ORDER BY scheduled_at ASC NULLS LAST, updated_at DESC;
+
+-- name: GetDraftById :one
+SELECT *
+FROM chirp_drafts
+WHERE id = $1;
+
+-- name: LockDraftById :one
+SELECT *
+FROM chirp_drafts
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateDraft :one
+UPDATE chirp_drafts
+SET body = $2,
+    scheduled_at = $3,
+    visibility = $4,
+    attempts = 0,
+    next_attempt_at = NULL,
+    last_error = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM chirp_drafts
+WHERE id = $1;
+
+-- name: ClaimDueDraft :one
+-- Locks the next due draft so exactly one scheduler, across all server
+-- instances, publishes it. Drafts of suspended or banned users wait until
+-- the restriction ends, and drafts that failed wait out their backoff.
+SELECT d.*
+FROM chirp_drafts d
+JOIN users u ON u.id = d.user_id
+WHERE d.scheduled_at <= NOW()
+    AND (d.next_attempt_at IS NULL OR d.next_attempt_at <= NOW())
+    AND u.banned_at IS NULL
+    AND (u.suspended_until IS NULL OR u.suspended_until <= NOW())
+ORDER BY d.scheduled_at
+LIMIT 1
+FOR UPDATE OF d SKIP LOCKED;
+
+-- name: RecordDraftPublishFailure :exec
+UPDATE chirp_drafts
+SET attempts = sqlc.arg(attempts),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_error = sqlc.arg(last_error),
+    scheduled_at = sqlc.arg(scheduled_at)
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,25 @@
+-- name: CreateChirpEvent :one
+INSERT INTO chirp_events (created_at, event, chirp_id, user_id, payload, visibility)
+VALUES (NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetChirpEventsAfter :many
+SELECT *
+FROM chirp_events
+WHERE id > $1
+ORDER BY id ASC
+LIMIT $2;
+
+-- name: GetRecentChirpEventsUpTo :many
+SELECT *
+FROM chirp_events
+WHERE id <= sqlc.arg(max_id) AND created_at > sqlc.arg(since)
+ORDER BY id ASC;
+
+-- name: GetLatestChirpEventId :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id
+FROM chirp_events;
+
+-- name: DeleteChirpEventsBefore :execrows
+DELETE FROM chirp_events
+WHERE created_at < $1;
@@ -1,17 +1,115 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3)
 RETURNING *;
 
 -- name: GetChirps :many
+-- Keyset-paginated on (created_at, id).
+-- Hides authors the viewer has blocked, been blocked by or muted, and
+-- chirps the viewer isn't in the audience of. Unlisted chirps stay off the
+-- timeline, and private accounts' chirps are only shown to their followers.
 SELECT *
 FROM chirps
-ORDER BY created_at ASC;
+WHERE hidden_at IS NULL
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = sqlc.narg(viewer_id) AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = sqlc.narg(viewer_id))
+        )
+    )
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM mutes m
+            WHERE m.muter_id = sqlc.narg(viewer_id)
+                AND m.muted_id = chirps.user_id
+        )
+    )
+    AND (
+        chirps.user_id = sqlc.narg(viewer_id)
+        OR (
+            chirps.visibility = 'public'
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.narg(viewer_id)
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        sqlc.narg(after_time)::timestamptz IS NULL
+        OR (created_at, id) > (sqlc.narg(after_time)::timestamptz, sqlc.narg(after_id)::uuid)
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.narg(row_limit)::int;
 
 -- name: GetChirpsDesc :many
+-- Keyset-paginated on (created_at, id).
+-- Hides authors the viewer has blocked, been blocked by or muted, and
+-- chirps the viewer isn't in the audience of. Unlisted chirps stay off the
+-- timeline, and private accounts' chirps are only shown to their followers.
 SELECT *
 FROM chirps
-ORDER BY created_at DESC;
+WHERE hidden_at IS NULL
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = sqlc.narg(viewer_id) AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = sqlc.narg(viewer_id))
+        )
+    )
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM mutes m
+            WHERE m.muter_id = sqlc.narg(viewer_id)
+                AND m.muted_id = chirps.user_id
+        )
+    )
+    AND (
+        chirps.user_id = sqlc.narg(viewer_id)
+        OR (
+            chirps.visibility = 'public'
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.narg(viewer_id)
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        sqlc.narg(before_time)::timestamptz IS NULL
+        OR (created_at, id) < (sqlc.narg(before_time)::timestamptz, sqlc.narg(before_id)::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.narg(row_limit)::int;
 
 -- name: GetChirpById :one
 SELECT *
@@ -23,13 +121,106 @@ DELETE FROM chirps
 WHERE id = $1; 
 
 -- name: GetChirpsByAuthorId :many
+-- Keyset-paginated on (created_at, id).
+-- Mutes only apply to the timeline, so an author's own page ignores them.
+-- Unlisted chirps appear here; followers-only and private ones only to
+-- their audience. A private account's chirps are all followers-only.
 SELECT *
 FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
+WHERE user_id = sqlc.arg(user_id)
+    AND hidden_at IS NULL
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = sqlc.narg(viewer_id) AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = sqlc.narg(viewer_id))
+        )
+    )
+    AND (
+        chirps.user_id = sqlc.narg(viewer_id)
+        OR (
+            chirps.visibility IN ('public', 'unlisted')
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'unlisted', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.narg(viewer_id)
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        sqlc.narg(after_time)::timestamptz IS NULL
+        OR (created_at, id) > (sqlc.narg(after_time)::timestamptz, sqlc.narg(after_id)::uuid)
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.narg(row_limit)::int;
 
 -- name: GetChirpsByAuthorIdDesc :many
+-- Keyset-paginated on (created_at, id).
+-- Mutes only apply to the timeline, so an author's own page ignores them.
+-- Unlisted chirps appear here; followers-only and private ones only to
+-- their audience. A private account's chirps are all followers-only.
+SELECT *
+FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+    AND hidden_at IS NULL
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = sqlc.narg(viewer_id) AND b.blocked_id = chirps.user_id)
+                OR (b.blocker_id = chirps.user_id AND b.blocked_id = sqlc.narg(viewer_id))
+        )
+    )
+    AND (
+        chirps.user_id = sqlc.narg(viewer_id)
+        OR (
+            chirps.visibility IN ('public', 'unlisted')
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = chirps.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            chirps.visibility IN ('public', 'unlisted', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.narg(viewer_id)
+                    AND f.followee_id = chirps.user_id
+            )
+        )
+    )
+    AND (
+        sqlc.narg(before_time)::timestamptz IS NULL
+        OR (created_at, id) < (sqlc.narg(before_time)::timestamptz, sqlc.narg(before_id)::uuid)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.narg(row_limit)::int;
+
+-- name: HideChirp :one
+UPDATE chirps
+SET hidden_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetAllChirpsByUserId :many
+-- Includes chirps hidden by moderators; used for data exports.
 SELECT *
 FROM chirps
 WHERE user_id = $1
-ORDER BY created_at DESC;
+ORDER BY created_at ASC;
@@ -0,0 +1,116 @@
+-- name: CreateCollection :one
+INSERT INTO collections (id, created_at, updated_at, user_id, name, description, is_public)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetCollectionById :one
+SELECT *
+FROM collections
+WHERE id = $1;
+
+-- name: LockCollectionById :one
+SELECT *
+FROM collections
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetCollectionsByUserId :many
+SELECT *
+FROM collections
+WHERE user_id = sqlc.arg(user_id)
+    AND (NOT sqlc.arg(public_only)::bool OR is_public)
+ORDER BY created_at ASC;
+
+-- name: UpdateCollection :one
+UPDATE collections
+SET name = $2,
+    description = $3,
+    is_public = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteCollection :exec
+DELETE FROM collections
+WHERE id = $1;
+
+-- name: AddCollectionItem :execrows
+-- Appends the chirp at the end of the collection.
+INSERT INTO collection_items (collection_id, chirp_id, position, added_at)
+SELECT sqlc.arg(collection_id)::uuid, sqlc.arg(chirp_id)::uuid, COALESCE(MAX(position), 0) + 1, NOW()
+FROM collection_items
+WHERE collection_id = sqlc.arg(collection_id)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveCollectionItem :execrows
+DELETE FROM collection_items
+WHERE collection_id = $1
+    AND chirp_id = $2;
+
+-- name: GetCollectionItemIds :many
+SELECT chirp_id
+FROM collection_items
+WHERE collection_id = $1
+ORDER BY position ASC;
+
+-- name: ReorderCollectionItems :execrows
+-- Positions follow the order of chirp_ids.
+UPDATE collection_items ci
+SET position = o.ordinality
+FROM unnest(sqlc.arg(chirp_ids)::uuid[]) WITH ORDINALITY AS o(chirp_id, ordinality)
+WHERE ci.collection_id = sqlc.arg(collection_id)
+    AND ci.chirp_id = o.chirp_id;
+
+-- name: GetCollectionChirps :many
+-- In collection order. Chirps the viewer can't see are left out. The cursor
+-- carries the sort key itself, so removing the item it was taken from
+-- doesn't end pagination.
+SELECT c.*, ci.position
+FROM collection_items ci
+JOIN chirps c ON c.id = ci.chirp_id
+WHERE ci.collection_id = sqlc.arg(collection_id)
+    AND (c.hidden_at IS NULL OR c.user_id = sqlc.narg(viewer_id))
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks bl
+            WHERE (bl.blocker_id = sqlc.narg(viewer_id) AND bl.blocked_id = c.user_id)
+                OR (bl.blocker_id = c.user_id AND bl.blocked_id = sqlc.narg(viewer_id))
+        )
+    )
+    AND (
+        c.user_id = sqlc.narg(viewer_id)
+        OR (
+            c.visibility IN ('public', 'unlisted')
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = c.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            c.visibility IN ('public', 'unlisted', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.narg(viewer_id)
+                    AND f.followee_id = c.user_id
+            )
+        )
+    )
+    AND (
+        sqlc.narg(after_position)::int IS NULL
+        OR (ci.position, ci.chirp_id) > (sqlc.narg(after_position)::int, sqlc.narg(after_id)::uuid)
+    )
+ORDER BY ci.position ASC, ci.chirp_id ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetCollectionItemsByUserId :many
+-- Used for data exports.
+SELECT ci.*
+FROM collection_items ci
+JOIN collections co ON co.id = ci.collection_id
+WHERE co.user_id = $1
+ORDER BY ci.collection_id, ci.position ASC;
@@ -0,0 +1,64 @@
+-- name: CreateConversation :one
+-- direct_key is set for one-to-one conversations only.
+INSERT INTO conversations (id, created_at, updated_at, created_by, direct_key)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+RETURNING *;
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+VALUES ($1, $2, NOW());
+
+-- name: GetConversationById :one
+SELECT *
+FROM conversations
+WHERE id = $1;
+
+-- name: GetConversationMembers :many
+SELECT *
+FROM conversation_members
+WHERE conversation_id = $1
+ORDER BY joined_at ASC, user_id ASC;
+
+-- name: GetDirectConversation :one
+-- Finds the existing one-to-one conversation for a member pair, if any.
+SELECT *
+FROM conversations
+WHERE direct_key = $1;
+
+-- name: GetConversationsByUserId :many
+SELECT c.*
+FROM conversations c
+JOIN conversation_members m ON m.conversation_id = c.id
+WHERE m.user_id = $1
+ORDER BY c.updated_at DESC;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_at = sqlc.arg(read_at)::timestamptz
+WHERE conversation_id = sqlc.arg(conversation_id)
+    AND user_id = sqlc.arg(user_id)
+    AND (last_read_at IS NULL OR last_read_at < sqlc.arg(read_at)::timestamptz);
+
+-- name: IsRefusedByConversationMember :one
+-- True if another member only accepts messages from people they follow and
+-- doesn't follow the sender. Policies can change after a conversation starts,
+-- so this is checked on every message, not just when it is created.
+SELECT EXISTS (
+    SELECT 1
+    FROM conversation_members cm
+    JOIN users u ON u.id = cm.user_id
+    WHERE cm.conversation_id = sqlc.arg(conversation_id)
+        AND cm.user_id <> sqlc.arg(sender_id)
+        AND u.dm_policy = 'following'
+        AND NOT EXISTS (
+            SELECT 1
+            FROM follows f
+            WHERE f.follower_id = cm.user_id
+                AND f.followee_id = sqlc.arg(sender_id)
+        )
+);
@@ -0,0 +1,54 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, user_id)
+VALUES (gen_random_uuid(), NOW(), $1)
+RETURNING *;
+
+-- name: GetActiveDataExportByUserId :one
+SELECT *
+FROM data_exports
+WHERE user_id = $1
+    AND status IN ('pending', 'running')
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetDataExportById :one
+SELECT *
+FROM data_exports
+WHERE id = $1
+    AND user_id = $2;
+
+-- name: ClaimDataExport :one
+-- Takes the oldest pending export, or one whose worker died mid-run, and
+-- marks it running so other workers skip it.
+UPDATE data_exports
+SET status = 'running',
+    started_at = NOW()
+WHERE id = (
+    SELECT id
+    FROM data_exports
+    WHERE status = 'pending'
+        OR (status = 'running' AND started_at < sqlc.arg(stale_before)::timestamptz)
+    ORDER BY created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready',
+    archive = $2,
+    completed_at = NOW(),
+    expires_at = $3
+WHERE id = $1;
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed',
+    error = $2,
+    completed_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_exports
+WHERE expires_at < NOW();
@@ -0,0 +1,18 @@
+-- name: CreateEmailChange :one
+INSERT INTO email_changes (token_hash, created_at, user_id, new_email, expires_at)
+VALUES ($1, NOW(), $2, $3, $4)
+RETURNING *;
+
+-- name: CancelPendingEmailChanges :exec
+-- Only the most recent request for a user stays valid.
+DELETE FROM email_changes
+WHERE user_id = $1
+    AND confirmed_at IS NULL;
+
+-- name: ConfirmEmailChange :one
+UPDATE email_changes
+SET confirmed_at = NOW()
+WHERE token_hash = $1
+    AND confirmed_at IS NULL
+    AND expires_at > NOW()
+RETURNING *;
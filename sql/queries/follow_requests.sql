@@ -0,0 +1,32 @@
+-- name: CreateFollowRequest :execrows
+INSERT INTO follow_requests (requester_id, target_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: GetFollowRequestsByTargetId :many
+SELECT *
+FROM follow_requests
+WHERE target_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests
+WHERE requester_id = $1
+    AND target_id = $2;
+
+-- name: RemoveFollowRequestsBetween :exec
+DELETE FROM follow_requests
+WHERE (requester_id = sqlc.arg(user_a) AND target_id = sqlc.arg(user_b))
+    OR (requester_id = sqlc.arg(user_b) AND target_id = sqlc.arg(user_a));
+
+-- name: ApproveAllFollowRequests :execrows
+-- Used when an account goes public: every pending request becomes a follow.
+WITH approved AS (
+    DELETE FROM follow_requests
+    WHERE target_id = $1
+    RETURNING requester_id, target_id
+)
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT requester_id, target_id, NOW()
+FROM approved
+ON CONFLICT DO NOTHING;
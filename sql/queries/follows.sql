@@ -0,0 +1,30 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1
+    AND followee_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1
+    FROM follows
+    WHERE follower_id = $1
+        AND followee_id = $2
+);
+
+-- name: GetFollowsByUserId :many
+-- Both directions: who the user follows and who follows them.
+SELECT *
+FROM follows
+WHERE follower_id = sqlc.arg(user_id)
+    OR followee_id = sqlc.arg(user_id)
+ORDER BY created_at ASC;
+
+-- name: GetFolloweeIds :many
+SELECT followee_id
+FROM follows
+WHERE follower_id = $1;
@@ -0,0 +1,65 @@
+-- name: QueueLinkPreview :exec
+-- Queues a URL for unfurling. Previews already cached are reused, and
+-- fetched again once they are more than a week old.
+INSERT INTO link_previews (url, created_at, updated_at, next_attempt_at)
+VALUES ($1, NOW(), NOW(), NOW())
+ON CONFLICT (url) DO UPDATE
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW(),
+    updated_at = NOW()
+WHERE link_previews.status <> 'pending'
+    AND link_previews.fetched_at < NOW() - INTERVAL '7 days';
+
+-- name: AddChirpLink :exec
+INSERT INTO chirp_links (chirp_id, url, position)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: ClaimDueLinkPreviews :many
+-- Leases due previews for a minute so that concurrent workers, including
+-- ones in other server instances, skip them while they are in flight.
+UPDATE link_previews
+SET next_attempt_at = NOW() + INTERVAL '1 minute',
+    updated_at = NOW()
+WHERE url IN (
+    SELECT url
+    FROM link_previews
+    WHERE status = 'pending'
+        AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkLinkPreviewReady :exec
+UPDATE link_previews
+SET status = 'ready',
+    attempts = attempts + 1,
+    fetched_at = NOW(),
+    last_error = NULL,
+    title = $2,
+    description = $3,
+    image_url = $4,
+    site_name = $5,
+    updated_at = NOW()
+WHERE url = $1;
+
+-- name: MarkLinkPreviewFailed :exec
+UPDATE link_previews
+SET status = $2,
+    attempts = $3,
+    next_attempt_at = $4,
+    fetched_at = NOW(),
+    last_error = $5,
+    updated_at = NOW()
+WHERE url = $1;
+
+-- name: GetLinkPreviewsByChirpIds :many
+SELECT l.chirp_id, p.url, p.title, p.description, p.image_url, p.site_name
+FROM chirp_links l
+JOIN link_previews p ON p.url = l.url
+WHERE l.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+    AND p.status = 'ready'
+ORDER BY l.chirp_id, l.position;
@@ -0,0 +1,108 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, user_id, name)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+RETURNING *;
+
+-- name: GetListById :one
+SELECT *
+FROM lists
+WHERE id = $1;
+
+-- name: LockListById :one
+SELECT *
+FROM lists
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetListsByUserId :many
+SELECT *
+FROM lists
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: UpdateList :one
+UPDATE lists
+SET name = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists
+WHERE id = $1;
+
+-- name: AddListMember :execrows
+INSERT INTO list_members (list_id, member_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1
+    AND member_id = $2;
+
+-- name: GetListMembers :many
+SELECT *
+FROM list_members
+WHERE list_id = $1
+ORDER BY created_at ASC;
+
+-- name: CountListMembers :one
+SELECT COUNT(*)
+FROM list_members
+WHERE list_id = $1;
+
+-- name: RemoveListMembershipsBetween :exec
+-- Drops each user from the other's lists; used when one blocks the other.
+DELETE FROM list_members lm
+USING lists l
+WHERE l.id = lm.list_id
+    AND (
+        (l.user_id = sqlc.arg(user_a)::uuid AND lm.member_id = sqlc.arg(user_b)::uuid)
+        OR (l.user_id = sqlc.arg(user_b)::uuid AND lm.member_id = sqlc.arg(user_a)::uuid)
+    );
+
+-- name: GetListTimeline :many
+-- Newest first, keyset-paginated on (created_at, id). Applies the same
+-- block and audience rules as the timeline, for the list's owner.
+SELECT c.*
+FROM chirps c
+WHERE c.user_id IN (
+        SELECT lm.member_id
+        FROM list_members lm
+        WHERE lm.list_id = sqlc.arg(list_id)
+    )
+    AND c.hidden_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM blocks b
+        WHERE (b.blocker_id = sqlc.arg(viewer_id) AND b.blocked_id = c.user_id)
+            OR (b.blocker_id = c.user_id AND b.blocked_id = sqlc.arg(viewer_id))
+    )
+    AND (
+        c.user_id = sqlc.arg(viewer_id)
+        OR (
+            c.visibility = 'public'
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = c.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            c.visibility IN ('public', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.arg(viewer_id)
+                    AND f.followee_id = c.user_id
+            )
+        )
+    )
+    AND (
+        sqlc.narg(before_time)::timestamptz IS NULL
+        OR (c.created_at, c.id) < (sqlc.narg(before_time)::timestamptz, sqlc.narg(before_id)::uuid)
+    )
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg(row_limit);
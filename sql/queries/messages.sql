@@ -0,0 +1,35 @@
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3)
+RETURNING *;
+
+-- name: GetMessageById :one
+SELECT *
+FROM messages
+WHERE id = $1;
+
+-- name: GetMessages :many
+SELECT *
+FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+    AND (
+        sqlc.narg(before)::uuid IS NULL
+        OR (created_at, id) < (SELECT m.created_at, m.id FROM messages m WHERE m.id = sqlc.narg(before))
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*)
+FROM messages msg
+JOIN conversation_members cm ON cm.conversation_id = msg.conversation_id
+WHERE msg.conversation_id = $1
+    AND cm.user_id = $2
+    AND msg.sender_id <> cm.user_id
+    AND (cm.last_read_at IS NULL OR msg.created_at > cm.last_read_at);
+
+-- name: GetMessagesBySenderId :many
+SELECT *
+FROM messages
+WHERE sender_id = $1
+ORDER BY created_at ASC;
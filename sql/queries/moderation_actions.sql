@@ -0,0 +1,10 @@
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, report_id, action, target_user_id, target_chirp_id, note)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetModerationActions :many
+SELECT *
+FROM moderation_actions
+ORDER BY created_at DESC
+LIMIT $1;
@@ -0,0 +1,20 @@
+-- name: MuteUser :execrows
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM mutes
+WHERE muter_id = $1
+    AND muted_id = $2;
+
+-- name: GetMutesByMuterId :many
+SELECT *
+FROM mutes
+WHERE muter_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetMutedUserIds :many
+SELECT muted_id
+FROM mutes
+WHERE muter_id = $1;
@@ -0,0 +1,90 @@
+-- name: CreateNotification :execrows
+-- Inserts nothing when the recipient has turned this notification type off,
+-- has muted the actor, or either of them has blocked the other.
+INSERT INTO notifications (id, created_at, user_id, actor_id, type, chirp_id)
+SELECT gen_random_uuid(), NOW(), sqlc.arg(user_id)::uuid, sqlc.arg(actor_id)::uuid, sqlc.arg(type)::text, sqlc.narg(chirp_id)::uuid
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM notification_preferences p
+    WHERE p.user_id = sqlc.arg(user_id)
+        AND p.type = sqlc.arg(type)
+        AND NOT p.enabled
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM blocks b
+    WHERE (b.blocker_id = sqlc.arg(user_id) AND b.blocked_id = sqlc.arg(actor_id))
+        OR (b.blocker_id = sqlc.arg(actor_id) AND b.blocked_id = sqlc.arg(user_id))
+)
+AND NOT EXISTS (
+    SELECT 1
+    FROM mutes m
+    WHERE m.muter_id = sqlc.arg(user_id)
+        AND m.muted_id = sqlc.arg(actor_id)
+);
+
+-- name: GetNotifications :many
+SELECT *
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+    AND (NOT sqlc.arg(unread_only)::bool OR read_at IS NULL)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM blocks b
+        WHERE (b.blocker_id = notifications.user_id AND b.blocked_id = notifications.actor_id)
+            OR (b.blocker_id = notifications.actor_id AND b.blocked_id = notifications.user_id)
+    )
+    AND (
+        sqlc.narg(before)::uuid IS NULL
+        OR (created_at, id) < (SELECT n.created_at, n.id FROM notifications n WHERE n.id = sqlc.narg(before))
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountUnreadNotifications :one
+-- Leaves out the same blocked actors as GetNotifications, so the count only
+-- covers notifications the user can actually see.
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = $1
+    AND read_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM blocks b
+        WHERE (b.blocker_id = notifications.user_id AND b.blocked_id = notifications.actor_id)
+            OR (b.blocker_id = notifications.actor_id AND b.blocked_id = notifications.user_id)
+    );
+
+-- name: MarkNotificationRead :execrows
+-- Matches already-read notifications too, so zero rows means the
+-- notification doesn't exist or isn't the caller's.
+UPDATE notifications
+SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1
+    AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :execrows
+-- Notifications hidden by a block stay unread, so unblocking brings them
+-- back as they were.
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+    AND read_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM blocks b
+        WHERE (b.blocker_id = notifications.user_id AND b.blocked_id = notifications.actor_id)
+            OR (b.blocker_id = notifications.actor_id AND b.blocked_id = notifications.user_id)
+    );
+
+-- name: GetNotificationPreferences :many
+SELECT *
+FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    updated_at = NOW();
@@ -0,0 +1,54 @@
+-- name: PinChirp :execrows
+INSERT INTO pinned_chirps (user_id, chirp_id, pinned_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1
+    AND chirp_id = $2;
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*)
+FROM pinned_chirps
+WHERE user_id = $1;
+
+-- name: GetPinnedChirpsByUserId :many
+-- Most recently pinned first, with the same block and audience rules as an
+-- author's page.
+SELECT c.*
+FROM pinned_chirps p
+JOIN chirps c ON c.id = p.chirp_id
+WHERE p.user_id = sqlc.arg(user_id)
+    AND c.hidden_at IS NULL
+    AND (
+        sqlc.narg(viewer_id)::uuid IS NULL
+        OR NOT EXISTS (
+            SELECT 1
+            FROM blocks b
+            WHERE (b.blocker_id = sqlc.narg(viewer_id) AND b.blocked_id = c.user_id)
+                OR (b.blocker_id = c.user_id AND b.blocked_id = sqlc.narg(viewer_id))
+        )
+    )
+    AND (
+        c.user_id = sqlc.narg(viewer_id)
+        OR (
+            c.visibility IN ('public', 'unlisted')
+            AND NOT EXISTS (
+                SELECT 1
+                FROM users u
+                WHERE u.id = c.user_id
+                    AND u.is_private
+            )
+        )
+        OR (
+            c.visibility IN ('public', 'unlisted', 'followers')
+            AND EXISTS (
+                SELECT 1
+                FROM follows f
+                WHERE f.follower_id = sqlc.narg(viewer_id)
+                    AND f.followee_id = c.user_id
+            )
+        )
+    )
+ORDER BY p.pinned_at DESC;
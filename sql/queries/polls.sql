@@ -0,0 +1,44 @@
+-- name: CreatePoll :one
+INSERT INTO polls (id, created_at, chirp_id, expires_at)
+VALUES (gen_random_uuid(), NOW(), $1, $2)
+RETURNING *;
+
+-- name: CreatePollOption :one
+INSERT INTO poll_options (id, poll_id, position, text)
+VALUES (gen_random_uuid(), $1, $2, $3)
+RETURNING *;
+
+-- name: GetPollByChirpId :one
+SELECT *
+FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPollOptionById :one
+SELECT *
+FROM poll_options
+WHERE id = $1;
+
+-- name: GetPollsByChirpIds :many
+SELECT *
+FROM polls
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetPollOptionTallies :many
+-- Options in display order, each with its current vote count.
+SELECT o.id, o.poll_id, o.position, o.text, COUNT(v.user_id) AS votes
+FROM poll_options o
+LEFT JOIN poll_votes v ON v.option_id = o.id
+WHERE o.poll_id = ANY(sqlc.arg(poll_ids)::uuid[])
+GROUP BY o.id
+ORDER BY o.poll_id, o.position;
+
+-- name: GetPollVotesByUserId :many
+SELECT *
+FROM poll_votes
+WHERE user_id = sqlc.arg(user_id)
+    AND poll_id = ANY(sqlc.arg(poll_ids)::uuid[]);
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT DO NOTHING;
@@ -16,3 +16,15 @@ FROM refresh_tokens
 WHERE token = $1
     AND (revoked_at IS NULL OR revoked_at > NOW())
     AND expires_at > NOW();
+
+-- name: RevokeAllUserTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1
+    AND revoked_at IS NULL;
+
+-- name: GetRefreshTokensByUserId :many
+SELECT *
+FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
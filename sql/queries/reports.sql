@@ -0,0 +1,42 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, reporter_id, reported_user_id, chirp_id, reason, details)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetModerationQueue :many
+-- Oldest first. Claims older than the lease are treated as abandoned and show
+-- up again alongside open reports.
+SELECT r.*, c.body AS chirp_body
+FROM reports r
+LEFT JOIN chirps c ON c.id = r.chirp_id
+WHERE r.status = 'open'
+    OR (r.status = 'claimed' AND r.claimed_at < sqlc.arg(claim_expired_before)::timestamptz)
+ORDER BY r.created_at ASC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed',
+    claimed_by = sqlc.arg(moderator_id),
+    claimed_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND (
+        status = 'open'
+        OR (status = 'claimed' AND (claimed_by = sqlc.arg(moderator_id) OR claimed_at < sqlc.arg(claim_expired_before)::timestamptz))
+    )
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved',
+    resolved_at = NOW(),
+    resolution = sqlc.arg(resolution)
+WHERE id = sqlc.arg(id)
+    AND status = 'claimed'
+    AND claimed_by = sqlc.arg(moderator_id)
+RETURNING *;
+
+-- name: GetReportById :one
+SELECT *
+FROM reports
+WHERE id = $1;
@@ -0,0 +1,30 @@
+-- name: UpsertSubscription :one
+INSERT INTO subscriptions (id, created_at, updated_at, user_id, status, period_end, grace_until)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET status = EXCLUDED.status,
+    period_end = EXCLUDED.period_end,
+    grace_until = EXCLUDED.grace_until,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetSubscriptionByUserId :one
+SELECT *
+FROM subscriptions
+WHERE user_id = $1;
+
+-- name: ExpireLapsedSubscriptions :execrows
+-- Statuses match the constants in internal/subscription; 'expired' is
+-- subscription.StatusExpired, which only an upgrade or renewal leaves.
+WITH lapsed AS (
+    UPDATE subscriptions
+    SET status = 'expired',
+        updated_at = NOW()
+    WHERE status IN ('active', 'past_due', 'grace_period')
+        AND COALESCE(grace_until, period_end) < NOW()
+    RETURNING user_id
+)
+UPDATE users
+SET is_chirpy_red = false,
+    updated_at = NOW()
+WHERE id IN (SELECT user_id FROM lapsed);
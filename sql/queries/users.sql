@@ -8,10 +8,28 @@ SELECT *
 FROM users
 WHERE email = $1;
 
--- name: UpdateUserCredential :one
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE handle = $1;
+
+-- name: UpdateUserHandle :one
+UPDATE users
+SET handle = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserEmail :one
 UPDATE users
 SET email = $2,
-    hashed_password = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
     updated_at = NOW()
 WHERE id = $1
 RETURNING *;
@@ -24,4 +42,93 @@ UPDATE users
 SET is_chirpy_red = true,
     updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: DowngradeFromChirpyRed :one
+UPDATE users
+SET is_chirpy_red = false,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserById :one
+SELECT *
+FROM users
+WHERE id = $1;
+
+-- name: LockUserById :one
+SELECT *
+FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateUserDmPolicy :one
+UPDATE users
+SET dm_policy = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPrivacy :one
+UPDATE users
+SET is_private = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SuspendUser :one
+UPDATE users
+SET suspended_until = $2,
+    restriction_reason = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: BanUser :one
+UPDATE users
+SET banned_at = NOW(),
+    restriction_reason = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ReinstateUser :one
+UPDATE users
+SET suspended_until = NULL,
+    banned_at = NULL,
+    restriction_reason = '',
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserRoleByEmail :one
+UPDATE users
+SET role = $2,
+    updated_at = NOW()
+WHERE email = $1
+RETURNING *;
+
+-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CancelUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUsersDueForDeletion :execrows
+DELETE FROM users
+WHERE deletion_scheduled_for <= NOW();
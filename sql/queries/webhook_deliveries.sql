@@ -0,0 +1,52 @@
+-- name: EnqueueWebhookDeliveries :execrows
+-- Fans an event out to every subscription listening for it. Account events
+-- pass the affected user so that only that user's subscriptions receive them.
+INSERT INTO webhook_deliveries (id, created_at, updated_at, subscription_id, event, payload, status, next_attempt_at)
+SELECT gen_random_uuid(), NOW(), NOW(), s.id, sqlc.arg(event)::text, sqlc.arg(payload)::jsonb, 'pending', NOW()
+FROM webhook_subscriptions s
+WHERE sqlc.arg(event)::text = ANY(s.events)
+    AND (sqlc.narg(user_id)::uuid IS NULL OR s.user_id = sqlc.narg(user_id));
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Leases due deliveries until lease_until so that concurrent workers,
+-- including ones in other server instances, skip them while they are in
+-- flight. The lease must outlast sending the whole batch.
+UPDATE webhook_deliveries d
+SET next_attempt_at = sqlc.arg(lease_until)::timestamptz,
+    updated_at = NOW()
+FROM webhook_subscriptions s
+WHERE d.subscription_id = s.id
+    AND d.id IN (
+        SELECT id
+        FROM webhook_deliveries
+        WHERE status = 'pending'
+            AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT sqlc.arg(row_limit)
+        FOR UPDATE SKIP LOCKED
+    )
+RETURNING d.id, d.event, d.payload, d.attempts, d.next_attempt_at, s.url, s.secret;
+
+-- name: MarkWebhookDeliveryAttempt :execrows
+-- Only applies while the caller still holds the lease it claimed: a worker
+-- whose lease ran out and was re-claimed elsewhere must not overwrite the
+-- newer result.
+UPDATE webhook_deliveries
+SET status = sqlc.arg(status),
+    attempts = sqlc.arg(attempts),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    last_status_code = sqlc.arg(last_status_code),
+    last_error = sqlc.arg(last_error),
+    delivered_at = sqlc.arg(delivered_at),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+    AND status = 'pending'
+    AND attempts = sqlc.arg(claimed_attempts)
+    AND next_attempt_at = sqlc.arg(leased_until)::timestamptz;
+
+-- name: GetWebhookDeliveriesBySubscriptionId :many
+SELECT *
+FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
@@ -0,0 +1,21 @@
+-- name: RecordWebhookEvent :one
+INSERT INTO webhook_events (id, created_at, updated_at, event, payload, status)
+VALUES ($1, NOW(), NOW(), $2, $3, 'received')
+ON CONFLICT (id) DO UPDATE
+SET deliveries = webhook_events.deliveries + 1,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: LockWebhookEvent :one
+SELECT *
+FROM webhook_events
+WHERE id = $1
+FOR UPDATE;
+
+-- name: MarkWebhookEvent :exec
+UPDATE webhook_events
+SET status = $2,
+    error = $3,
+    processed_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE subscriptions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id UUID UNIQUE REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    status TEXT NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    grace_until TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS subscriptions;
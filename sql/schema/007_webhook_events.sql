@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE webhook_events (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    deliveries INTEGER NOT NULL DEFAULT 1,
+    processed_at TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_events;
@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    type TEXT NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    read_at TIMESTAMPTZ
+);
+
+CREATE INDEX notifications_user_created_idx ON notifications (user_id, created_at DESC, id DESC);
+
+CREATE TABLE notification_preferences (
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    type TEXT NOT NULL,
+    enabled BOOL NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION notify_notification() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('notifications', json_build_object(
+        'id', NEW.id,
+        'created_at', NEW.created_at,
+        'user_id', NEW.user_id,
+        'actor_id', NEW.actor_id,
+        'type', NEW.type,
+        'chirp_id', NEW.chirp_id,
+        'read_at', NEW.read_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER notifications_notify
+AFTER INSERT ON notifications
+FOR EACH ROW EXECUTE FUNCTION notify_notification();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS notifications_notify ON notifications;
+DROP FUNCTION IF EXISTS notify_notification();
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notifications;
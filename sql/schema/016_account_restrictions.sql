@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN banned_at TIMESTAMPTZ,
+ADD COLUMN restriction_reason TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE appeals (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    response TEXT NOT NULL DEFAULT ''
+);
+
+-- One open appeal per user at a time.
+CREATE UNIQUE INDEX appeals_open_user_idx ON appeals (user_id) WHERE status = 'open';
+
+-- +goose Down
+DROP TABLE IF EXISTS appeals;
+ALTER TABLE users DROP COLUMN IF EXISTS restriction_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS banned_at;
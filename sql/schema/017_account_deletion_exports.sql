@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN deletion_scheduled_for TIMESTAMPTZ;
+
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    archive BYTEA,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX data_exports_user_idx ON data_exports (user_id, created_at DESC);
+CREATE INDEX data_exports_pending_idx ON data_exports (created_at) WHERE status IN ('pending', 'running');
+
+-- +goose Down
+DROP TABLE IF EXISTS data_exports;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_for;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE email_changes (
+    token_hash TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    new_email TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    confirmed_at TIMESTAMPTZ
+);
+
+CREATE INDEX email_changes_user_idx ON email_changes (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_changes;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE chirp_drafts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    body TEXT NOT NULL,
+    scheduled_at TIMESTAMPTZ
+);
+
+CREATE INDEX chirp_drafts_user_idx ON chirp_drafts (user_id, updated_at DESC);
+CREATE INDEX chirp_drafts_scheduled_idx ON chirp_drafts (scheduled_at) WHERE scheduled_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_drafts;
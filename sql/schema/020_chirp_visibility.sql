@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'unlisted', 'followers', 'private'));
+
+ALTER TABLE chirp_drafts
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'unlisted', 'followers', 'private'));
+
+-- Copied onto each event so live streams can filter without a lookup, and
+-- so a deleted chirp's event keeps the audience it had.
+ALTER TABLE chirp_events
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+-- +goose Down
+ALTER TABLE chirp_events DROP COLUMN IF EXISTS visibility;
+ALTER TABLE chirp_drafts DROP COLUMN IF EXISTS visibility;
+ALTER TABLE chirps DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE lists (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE INDEX lists_user_idx ON lists (user_id, created_at);
+
+CREATE TABLE list_members (
+    list_id UUID REFERENCES lists(id) ON DELETE CASCADE NOT NULL,
+    member_id UUID REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (list_id, member_id)
+);
+
+CREATE INDEX chirps_user_created_idx ON chirps (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_user_created_idx;
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
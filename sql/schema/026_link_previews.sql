@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE link_previews (
+    url TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    fetched_at TIMESTAMPTZ,
+    last_error TEXT,
+    title TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    image_url TEXT NOT NULL DEFAULT '',
+    site_name TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX link_previews_pending_idx ON link_previews (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE chirp_links (
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE NOT NULL,
+    url TEXT REFERENCES link_previews(url) ON DELETE CASCADE NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, url)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_links;
+DROP TABLE IF EXISTS link_previews;
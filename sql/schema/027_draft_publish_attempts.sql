@@ -0,0 +1,14 @@
+-- +goose Up
+-- A scheduled draft that fails to publish is retried with backoff and, after
+-- too many failures, unscheduled with the error kept for its author, so one
+-- bad draft can't hold up the rest of the queue.
+ALTER TABLE chirp_drafts
+ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+ADD COLUMN next_attempt_at TIMESTAMPTZ,
+ADD COLUMN last_error TEXT;
+
+-- +goose Down
+ALTER TABLE chirp_drafts
+DROP COLUMN IF EXISTS last_error,
+DROP COLUMN IF EXISTS next_attempt_at,
+DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Handles are how chirps mention people. They are stored lowercase and are
+-- optional, so accounts created before handles existed can pick one later.
+ALTER TABLE users
+ADD COLUMN handle TEXT UNIQUE
+CHECK (handle ~ '^[a-z0-9_]{3,20}$');
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS handle;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- A conversation belongs to all of its members, so the creator deleting
+-- their account shouldn't delete it for everyone else.
+ALTER TABLE conversations
+ALTER COLUMN created_by DROP NOT NULL,
+DROP CONSTRAINT conversations_created_by_fkey,
+ADD CONSTRAINT conversations_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM conversations WHERE created_by IS NULL;
+ALTER TABLE conversations
+DROP CONSTRAINT conversations_created_by_fkey,
+ADD CONSTRAINT conversations_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+ALTER COLUMN created_by SET NOT NULL;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- One-to-one conversations are keyed on their sorted member pair rather
+-- than recognized by having two members, so a group that has shrunk to two
+-- people is never mistaken for their direct thread. Members only ever left
+-- by deleting their account, so existing two-member conversations that
+-- still include their creator are taken to be direct.
+ALTER TABLE conversations ADD COLUMN direct_key TEXT UNIQUE;
+
+UPDATE conversations c
+SET direct_key = pair.direct_key
+FROM (
+    SELECT conversation_id, MIN(user_id::text) || ':' || MAX(user_id::text) AS direct_key
+    FROM conversation_members
+    GROUP BY conversation_id
+    HAVING COUNT(*) = 2
+) pair
+WHERE pair.conversation_id = c.id
+    AND EXISTS (
+        SELECT 1
+        FROM conversation_members cm
+        WHERE cm.conversation_id = c.id
+            AND cm.user_id = c.created_by
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM conversations other
+        WHERE other.direct_key = pair.direct_key
+    );
+
+-- +goose Down
+ALTER TABLE conversations DROP COLUMN IF EXISTS direct_key;
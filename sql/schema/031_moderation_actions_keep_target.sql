@@ -0,0 +1,16 @@
+-- +goose Up
+-- The moderation log is an audit trail, so it outlives the accounts it
+-- names. Deleting an account clears the target instead of its history.
+ALTER TABLE moderation_actions
+ALTER COLUMN target_user_id DROP NOT NULL,
+DROP CONSTRAINT moderation_actions_target_user_id_fkey,
+ADD CONSTRAINT moderation_actions_target_user_id_fkey
+    FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM moderation_actions WHERE target_user_id IS NULL;
+ALTER TABLE moderation_actions
+DROP CONSTRAINT moderation_actions_target_user_id_fkey,
+ADD CONSTRAINT moderation_actions_target_user_id_fkey
+    FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE CASCADE,
+ALTER COLUMN target_user_id SET NOT NULL;
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	chirpEventsChannel     = "chirp_events"
+	chirpEventBatchSize    = 500
+	chirpEventRetention    = 24 * time.Hour
+	chirpEventCommitLag    = time.Minute
+	streamSubscriberBuffer = 64
+	streamHeartbeat        = 15 * time.Second
+)
+
+// chirpStream fans chirp events out to connected stream clients. Events are
+// written to chirp_events by whichever server instance handled the write; a
+// trigger turns each insert into a NOTIFY so every instance picks it up.
+//
+// Event ids are taken from a sequence before the inserting transaction
+// commits, so a lower id can become visible after a higher one. Events are
+// therefore not strictly ordered by id: the last chirpEventCommitLag is
+// re-scanned for late commits, and clients must deduplicate by id.
+type chirpStream struct {
+	queries *database.Queries
+
+	mu          sync.Mutex
+	subscribers map[chan database.ChirpEvent]struct{}
+
+	// lastID and recent are only touched by run.
+	lastID int64
+	recent map[int64]time.Time
+}
+
+func newChirpStream(queries *database.Queries) *chirpStream {
+	return &chirpStream{
+		queries:     queries,
+		subscribers: make(map[chan database.ChirpEvent]struct{}),
+		recent:      make(map[int64]time.Time),
+	}
+}
+
+// run listens for notifications until ctx is done. Notifications only carry
+// the event id, so after each one (and after a reconnect, when some may have
+// been missed) it reads every event newer than the last one broadcast.
+func (s *chirpStream) run(ctx context.Context, dbURL string) {
+	lastID, err := s.queries.GetLatestChirpEventId(ctx)
+	if err != nil {
+		log.Printf("Error loading latest chirp event: %s", err)
+	}
+	s.lastID = lastID
+
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Error listening for chirp events: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(chirpEventsChannel); err != nil {
+		log.Printf("Error listening on %s: %s", chirpEventsChannel, err)
+		return
+	}
+
+	prune := time.NewTicker(time.Hour)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-listener.Notify:
+			s.broadcastPending(ctx)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+			s.broadcastPending(ctx)
+		case <-prune.C:
+			if _, err := s.queries.DeleteChirpEventsBefore(ctx, time.Now().Add(-chirpEventRetention)); err != nil {
+				log.Printf("Error pruning chirp events: %s", err)
+			}
+		}
+	}
+}
+
+func (s *chirpStream) broadcastPending(ctx context.Context) {
+	since := time.Now().Add(-chirpEventCommitLag)
+
+	late, err := s.queries.GetRecentChirpEventsUpTo(ctx,
+		database.GetRecentChirpEventsUpToParams{
+			MaxID: s.lastID,
+			Since: since,
+		},
+	)
+	if err != nil {
+		log.Printf("Error loading chirp events: %s", err)
+		return
+	}
+	for _, event := range late {
+		if _, ok := s.recent[event.ID]; !ok {
+			s.broadcast(event)
+			s.recent[event.ID] = event.CreatedAt
+		}
+	}
+
+	defer func() {
+		for id, createdAt := range s.recent {
+			if !createdAt.After(since) {
+				delete(s.recent, id)
+			}
+		}
+	}()
+
+	for {
+		events, err := s.queries.GetChirpEventsAfter(ctx,
+			database.GetChirpEventsAfterParams{
+				ID:    s.lastID,
+				Limit: chirpEventBatchSize,
+			},
+		)
+		if err != nil {
+			log.Printf("Error loading chirp events: %s", err)
+			return
+		}
+
+		for _, event := range events {
+			s.broadcast(event)
+			s.lastID = event.ID
+			s.recent[event.ID] = event.CreatedAt
+		}
+
+		if len(events) < chirpEventBatchSize {
+			return
+		}
+	}
+}
+
+// broadcast hands event to every subscriber without blocking. A subscriber
+// whose buffer is full is disconnected; its client can resume with
+// Last-Event-ID instead of holding up everyone else.
+func (s *chirpStream) broadcast(event database.ChirpEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *chirpStream) subscribe() chan database.ChirpEvent {
+	ch := make(chan database.ChirpEvent, streamSubscriberBuffer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers[ch] = struct{}{}
+
+	return ch
+}
+
+func (s *chirpStream) unsubscribe(ch chan database.ChirpEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (cfg *apiConfig) handlerStreamChirps(w http.ResponseWriter, r *http.Request) {
+	var (
+		authorID    uuid.UUID
+		hasAuthorID bool
+		lastEventID int64
+		filter      audienceFilter
+	)
+
+	viewerID, err := cfg.getOptionalViewer(r)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	if authorIDParam := r.URL.Query().Get("author_id"); authorIDParam != "" {
+		parsedAuthorID, err := uuid.Parse(authorIDParam)
+		if err != nil {
+			writeErrorResponse(w, fmt.Errorf("invalid author_id %q: %w", authorIDParam, err), http.StatusBadRequest)
+			return
+		}
+		authorID = parsedAuthorID
+		hasAuthorID = true
+	}
+
+	if lastEventIDHeader := r.Header.Get("Last-Event-ID"); lastEventIDHeader != "" {
+		parsedLastEventID, err := strconv.ParseInt(lastEventIDHeader, 10, 64)
+		if err != nil {
+			writeErrorResponse(w, fmt.Errorf("invalid Last-Event-ID %q: %w", lastEventIDHeader, err), http.StatusBadRequest)
+			return
+		}
+		lastEventID = parsedLastEventID
+	}
+
+	if viewerID.Valid {
+		filter, err = loadAudienceFilter(r.Context(), cfg.queries, viewerID.UUID)
+		if err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeErrorResponse(w, fmt.Errorf("streaming unsupported"), http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost;
+	// anything seen during replay is skipped below.
+	events := cfg.chirpStream.subscribe()
+	defer cfg.chirpStream.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// sent holds the ids already written on this connection, back to
+	// chirpEventCommitLag, since late commits can repeat or reorder ids.
+	sent := make(map[int64]time.Time)
+	send := func(event database.ChirpEvent) {
+		if _, ok := sent[event.ID]; ok {
+			return
+		}
+		sent[event.ID] = event.CreatedAt
+		if hasAuthorID && event.UserID != authorID {
+			return
+		}
+		if filter.hides(event, !hasAuthorID) {
+			return
+		}
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Event, event.Payload)
+	}
+
+	// Replay everything after Last-Event-ID plus the trailing window before
+	// it, which may hold events that committed after the client saw a higher
+	// id. Clients drop the ones they already have.
+	if lastEventID > 0 {
+		late, err := cfg.queries.GetRecentChirpEventsUpTo(r.Context(),
+			database.GetRecentChirpEventsUpToParams{
+				MaxID: lastEventID,
+				Since: time.Now().Add(-chirpEventCommitLag),
+			},
+		)
+		if err != nil {
+			log.Printf("Error replaying chirp events: %s", err)
+			return
+		}
+		for _, event := range late {
+			send(event)
+		}
+
+		cursor := lastEventID
+		for {
+			missed, err := cfg.queries.GetChirpEventsAfter(r.Context(),
+				database.GetChirpEventsAfterParams{
+					ID:    cursor,
+					Limit: chirpEventBatchSize,
+				},
+			)
+			if err != nil {
+				log.Printf("Error replaying chirp events: %s", err)
+				return
+			}
+			for _, event := range missed {
+				send(event)
+				cursor = event.ID
+			}
+			if len(missed) < chirpEventBatchSize {
+				break
+			}
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			send(event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			since := time.Now().Add(-2 * chirpEventCommitLag)
+			for id, createdAt := range sent {
+				if createdAt.Before(since) {
+					delete(sent, id)
+				}
+			}
+			// Pick up blocks, mutes and follows made since the stream was opened.
+			if viewerID.Valid {
+				if refreshed, err := loadAudienceFilter(r.Context(), cfg.queries, viewerID.UUID); err == nil {
+					filter = refreshed
+				}
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runSubscriptionExpiry periodically downgrades users whose subscription
+// period (or grace period) has lapsed without a renewal event from Polka.
+func (cfg *apiConfig) runSubscriptionExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.expireSubscriptions(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) expireSubscriptions(ctx context.Context) {
+	expired, err := cfg.queries.ExpireLapsedSubscriptions(ctx)
+	if err != nil {
+		log.Printf("Error expiring subscriptions: %s", err)
+		return
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d lapsed subscriptions", expired)
+	}
+}
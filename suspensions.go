@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	moderationActionBanUser       = "ban_user"
+	moderationActionReinstateUser = "reinstate_user"
+	moderationActionRejectAppeal  = "reject_appeal"
+)
+
+const (
+	appealStatusAccepted = "accepted"
+	appealStatusRejected = "rejected"
+)
+
+const (
+	maxRestrictionReason = 500
+	maxAppealBody        = 2000
+)
+
+type appealResponse struct {
+	Id         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserId     uuid.UUID  `json:"user_id"`
+	Body       string     `json:"body"`
+	Status     string     `json:"status"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	Response   string     `json:"response"`
+}
+
+// accountRestriction returns an error describing why user may not log in or
+// write, or nil for an account in good standing. The message is shown to the
+// user, so it carries the moderator's reason.
+func accountRestriction(user database.User) error {
+	reason := ""
+	if user.RestrictionReason != "" {
+		reason = ": " + user.RestrictionReason
+	}
+
+	if user.BannedAt.Valid {
+		return fmt.Errorf("account banned%s", reason)
+	}
+	if user.SuspendedUntil.Valid && user.SuspendedUntil.Time.After(time.Now()) {
+		return fmt.Errorf("account suspended until %s%s", user.SuspendedUntil.Time.Format(time.RFC3339), reason)
+	}
+	return nil
+}
+
+// suspendAccount suspends the user until the given time and revokes their
+// refresh tokens so existing sessions can't outlive the access token.
+func suspendAccount(ctx context.Context, q *database.Queries, userId uuid.UUID, until time.Time, reason string) error {
+	_, err := q.SuspendUser(ctx,
+		database.SuspendUserParams{
+			ID:                userId,
+			SuspendedUntil:    sql.NullTime{Time: until, Valid: true},
+			RestrictionReason: reason,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return q.RevokeAllUserTokens(ctx, userId)
+}
+
+// banAccount bans the user indefinitely and revokes their refresh tokens.
+func banAccount(ctx context.Context, q *database.Queries, userId uuid.UUID, reason string) error {
+	_, err := q.BanUser(ctx,
+		database.BanUserParams{
+			ID:                userId,
+			RestrictionReason: reason,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return q.RevokeAllUserTokens(ctx, userId)
+}
+
+// middlewareActiveAccount rejects requests made with an access token
+// belonging to a suspended or banned user. Access tokens live for an hour, so
+// revoking refresh tokens alone would leave a window where a restricted user
+// could still write. main wraps every authenticated write with it except
+// appeals, account deletion and data export, which restricted users must
+// still be able to reach. Requests without a valid access token pass through
+// untouched; the handler decides whether they need one.
+func (cfg *apiConfig) middlewareActiveAccount(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userId, err := auth.ValidateJWT(token, cfg.secret)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := cfg.queries.GetUserById(r.Context(), userId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeErrorResponse(w, errors.New("account not found"), http.StatusUnauthorized)
+				return
+			}
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		if err := accountRestriction(user); err != nil {
+			writeErrorResponse(w, err, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *apiConfig) handlerSuspendUser(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Days   int    `json:"days"`
+		Reason string `json:"reason"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if param.Days == 0 {
+		param.Days = defaultSuspensionDays
+	}
+	if param.Days < 1 || param.Days > maxSuspensionDays {
+		writeErrorResponse(w, fmt.Errorf("days must be between 1 and %d", maxSuspensionDays), http.StatusBadRequest)
+		return
+	}
+
+	cfg.restrictUser(w, r, moderationActionSuspendUser, param.Reason, func(ctx context.Context, q *database.Queries, userId uuid.UUID) error {
+		return suspendAccount(ctx, q, userId, time.Now().AddDate(0, 0, param.Days), param.Reason)
+	})
+}
+
+func (cfg *apiConfig) handlerBanUser(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Reason string `json:"reason"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	cfg.restrictUser(w, r, moderationActionBanUser, param.Reason, func(ctx context.Context, q *database.Queries, userId uuid.UUID) error {
+		return banAccount(ctx, q, userId, param.Reason)
+	})
+}
+
+func (cfg *apiConfig) handlerReinstateUser(w http.ResponseWriter, r *http.Request) {
+	cfg.restrictUser(w, r, moderationActionReinstateUser, "", func(ctx context.Context, q *database.Queries, userId uuid.UUID) error {
+		_, err := q.ReinstateUser(ctx, userId)
+		return err
+	})
+}
+
+// restrictUser applies a direct moderator action to the user in the {id}
+// path value and records it in the audit log, all in one transaction.
+func (cfg *apiConfig) restrictUser(w http.ResponseWriter, r *http.Request, action, reason string, apply func(context.Context, *database.Queries, uuid.UUID) error) {
+	moderatorId := userIDFromContext(r.Context())
+
+	if len(reason) > maxRestrictionReason {
+		writeErrorResponse(w, fmt.Errorf("reason is limited to %d characters", maxRestrictionReason), http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	targetId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	if targetId == moderatorId {
+		writeErrorResponse(w, errors.New("cannot moderate your own account"), http.StatusBadRequest)
+		return
+	}
+
+	target, err := cfg.queries.GetUserById(r.Context(), targetId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("user not found"), http.StatusNotFound)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Otherwise a moderator could lock out an admin.
+	if target.Role != auth.RoleUser {
+		writeErrorResponse(w, errors.New("staff accounts must be demoted first"), http.StatusForbidden)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	if err := apply(r.Context(), qtx, targetId); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	_, err = qtx.CreateModerationAction(r.Context(),
+		database.CreateModerationActionParams{
+			ModeratorID:  uuid.NullUUID{UUID: moderatorId, Valid: true},
+			Action:       action,
+			TargetUserID: uuid.NullUUID{UUID: targetId, Valid: true},
+			Note:         reason,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+// handlerCreateAppeal lets a restricted user contest the decision. They
+// can't obtain an access token, so the request carries their credentials.
+func (cfg *apiConfig) handlerCreateAppeal(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Body     string `json:"body"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	param.Body = strings.TrimSpace(param.Body)
+	if param.Body == "" {
+		writeErrorResponse(w, errors.New("body is required"), http.StatusBadRequest)
+		return
+	}
+	if len(param.Body) > maxAppealBody {
+		writeErrorResponse(w, fmt.Errorf("body is limited to %d characters", maxAppealBody), http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.queries.GetUserByEmail(r.Context(), strings.TrimSpace(param.Email))
+	if err != nil {
+		writeErrorResponse(w, errors.New("incorrect email or password"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := auth.CheckPasswordHash(param.Password, user.HashedPassword); err != nil {
+		writeErrorResponse(w, errors.New("incorrect email or password"), http.StatusUnauthorized)
+		return
+	}
+
+	if accountRestriction(user) == nil {
+		writeErrorResponse(w, errors.New("account is not restricted"), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cfg.queries.GetOpenAppealByUserId(r.Context(), user.ID); err == nil {
+		writeErrorResponse(w, errors.New("an appeal is already under review"), http.StatusConflict)
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	appeal, err := cfg.queries.CreateAppeal(r.Context(),
+		database.CreateAppealParams{
+			UserID: user.ID,
+			Body:   param.Body,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toAppealResponse(appeal), http.StatusCreated)
+}
+
+func (cfg *apiConfig) handlerGetAppeals(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseModerationLimit(w, r)
+	if !ok {
+		return
+	}
+
+	appeals, err := cfg.queries.GetOpenAppeals(r.Context(), int32(limit))
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]appealResponse, 0, len(appeals))
+	for _, appeal := range appeals {
+		responses = append(responses, toAppealResponse(appeal))
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+// handlerResolveAppeal accepts or rejects an open appeal. Accepting one
+// lifts the suspension or ban.
+func (cfg *apiConfig) handlerResolveAppeal(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Accept   bool   `json:"accept"`
+		Response string `json:"response"`
+	}
+
+	moderatorId := userIDFromContext(r.Context())
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	appealId, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return
+	}
+
+	status, action := appealStatusRejected, moderationActionRejectAppeal
+	if param.Accept {
+		status, action = appealStatusAccepted, moderationActionReinstateUser
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	appeal, err := qtx.ResolveAppeal(r.Context(),
+		database.ResolveAppealParams{
+			ID:         appealId,
+			Status:     status,
+			ReviewedBy: uuid.NullUUID{UUID: moderatorId, Valid: true},
+			Response:   param.Response,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := cfg.queries.GetAppealById(r.Context(), appealId); errors.Is(err, sql.ErrNoRows) {
+				writeErrorResponse(w, fmt.Errorf("appeal not found"), http.StatusNotFound)
+				return
+			}
+			writeErrorResponse(w, errors.New("appeal is already resolved"), http.StatusConflict)
+			return
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if param.Accept {
+		if _, err := qtx.ReinstateUser(r.Context(), appeal.UserID); err != nil {
+			writeErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	_, err = qtx.CreateModerationAction(r.Context(),
+		database.CreateModerationActionParams{
+			ModeratorID:  uuid.NullUUID{UUID: moderatorId, Valid: true},
+			Action:       action,
+			TargetUserID: uuid.NullUUID{UUID: appeal.UserID, Valid: true},
+			Note:         param.Response,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, toAppealResponse(appeal), http.StatusOK)
+}
+
+func toAppealResponse(appeal database.Appeal) appealResponse {
+	res := appealResponse{
+		Id:        appeal.ID,
+		CreatedAt: appeal.CreatedAt,
+		UserId:    appeal.UserID,
+		Body:      appeal.Body,
+		Status:    appeal.Status,
+		Response:  appeal.Response,
+	}
+	if appeal.ReviewedAt.Valid {
+		res.ReviewedAt = &appeal.ReviewedAt.Time
+	}
+	return res
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/dberr"
 )
 
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
@@ -20,11 +23,30 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 
 	refreshToken, err := cfg.queries.GetRefreshTokenByToken(r.Context(), token)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusUnauthorized)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, errors.New("invalid refresh token"), http.StatusUnauthorized)
+			return
+		}
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
-	newToken, err := auth.MakeJWT(refreshToken.UserID, cfg.secret, time.Hour)
+	user, err := cfg.queries.GetUserById(r.Context(), refreshToken.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, errors.New("account not found"), http.StatusUnauthorized)
+			return
+		}
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if err := accountRestriction(user); err != nil {
+		writeErrorResponse(w, err, http.StatusForbidden)
+		return
+	}
+
+	newToken, err := auth.MakeJWT(user.ID, user.Role, cfg.secret, time.Hour)
 	if err != nil {
 		writeErrorResponse(w, err, http.StatusInternalServerError)
 		return
@@ -45,7 +67,7 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 
 	err = cfg.queries.RevokeUserToken(r.Context(), token)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusInternalServerError)
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
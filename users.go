@@ -2,22 +2,31 @@ package main
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
 	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/dberr"
+	"github.com/Sanghun1Adam1Park/chirp/internal/mailer"
 	"github.com/google/uuid"
 )
 
+const emailChangeTTL = 24 * time.Hour
+
+// dummyPasswordHash is checked against when a login names an unknown email,
+// so that path costs the same bcrypt work as a wrong password. It uses the
+// same cost as auth.HashPassword.
+const dummyPasswordHash = "$2a$10$RvvdQ1Oq1.Swy6AfTQu44OMPXstmx.yaMRWOz0g6Cn8O1znDOPv5e"
+
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameter struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password string `json:"password" validate:"required"`
+		Email    string `json:"email" validate:"required,email"`
 	}
 	type response struct {
 		Id          uuid.UUID `json:"id"`
@@ -27,18 +36,16 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		IsChirpyRed bool      `json:"is_chirpy_red"`
 	}
 
-	data, err := io.ReadAll(r.Body)
-	if err != nil {
-		writeErrorResponse(w, err, http.StatusInternalServerError)
-		return
-	}
-
-	var param parameter
-	if err := json.Unmarshal(data, &param); err != nil {
+	param := parameter{}
+	if err := decodeJSON(w, r, &param); err != nil {
 		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
+	// The email rule ignores surrounding whitespace, so strip it before the
+	// address is stored and compared against later logins.
+	param.Email = strings.TrimSpace(param.Email)
+
 	hashedPassword, err := auth.HashPassword(param.Password)
 	if err != nil {
 		writeErrorResponse(w, err, http.StatusInternalServerError)
@@ -52,11 +59,7 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		},
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			writeErrorResponse(w, fmt.Errorf("chirp not found"), http.StatusNotFound)
-			return
-		}
-		writeErrorResponse(w, err, http.StatusConflict)
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
@@ -72,8 +75,8 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 
 func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	type parameter struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password string `json:"password" validate:"required"`
+		Email    string `json:"email" validate:"required"`
 	}
 	type response struct {
 		Id           uuid.UUID `json:"id"`
@@ -81,25 +84,29 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt    time.Time `json:"updated_at"`
 		Email        string    `json:"email"`
 		IsChirpyRed  bool      `json:"is_chirpy_red"`
+		Role         string    `json:"role"`
 		Token        string    `json:"token"`
 		RefreshToken string    `json:"refresh_token"`
 	}
 
-	data, err := io.ReadAll(r.Body)
-	if err != nil {
-		writeErrorResponse(w, err, http.StatusInternalServerError)
-		return
-	}
-
-	var param parameter
-	if err := json.Unmarshal(data, &param); err != nil {
+	param := parameter{}
+	if err := decodeJSON(w, r, &param); err != nil {
 		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
+	param.Email = strings.TrimSpace(param.Email)
+
+	// Unknown emails get the same answer as wrong passwords, and take as
+	// long to get it, so logins can't be used to find out who has an account.
 	user, err := cfg.queries.GetUserByEmail(r.Context(), param.Email)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusNotFound)
+		if errors.Is(err, sql.ErrNoRows) {
+			auth.CheckPasswordHash(param.Password, dummyPasswordHash)
+			writeErrorResponse(w, errInvalidCredentials, http.StatusUnauthorized)
+			return
+		}
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
@@ -108,7 +115,12 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jwt, err := auth.MakeJWT(user.ID, cfg.secret, time.Second*60*60)
+	if err := accountRestriction(user); err != nil {
+		writeErrorResponse(w, err, http.StatusForbidden)
+		return
+	}
+
+	jwt, err := auth.MakeJWT(user.ID, user.Role, cfg.secret, time.Second*60*60)
 	if err != nil {
 		writeErrorResponse(w, err, http.StatusInternalServerError)
 		return
@@ -127,6 +139,10 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 			ExpiresAt: time.Now().AddDate(0, 0, 60),
 		},
 	)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
 
 	res := response{
 		Id:           user.ID,
@@ -134,23 +150,19 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:    user.UpdatedAt,
 		Email:        user.Email,
 		IsChirpyRed:  user.IsChirpyRed,
+		Role:         user.Role,
 		Token:        jwt,
 		RefreshToken: refreshToken.Token,
 	}
 	writeSuccessResponse(w, res, http.StatusOK)
 }
 
-func (cfg *apiConfig) handlerUpdateCrendentials(w http.ResponseWriter, r *http.Request) {
+// handlerChangeEmail starts an email change. The address only changes once
+// the link sent to it is followed, proving the user controls it.
+func (cfg *apiConfig) handlerChangeEmail(w http.ResponseWriter, r *http.Request) {
 	type parameter struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
-	}
-	type response struct {
-		Id          uuid.UUID `json:"id"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
-		Email       string    `json:"email"`
-		IsChirpyRed bool      `json:"is_chirpy_red"`
+		NewEmail        string `json:"new_email" validate:"required,email"`
+		CurrentPassword string `json:"current_password" validate:"required"`
 	}
 
 	token, err := auth.GetBearerToken(r.Header)
@@ -159,54 +171,182 @@ func (cfg *apiConfig) handlerUpdateCrendentials(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
 	param := parameter{}
-	if err := decoder.Decode(&param); err != nil {
+	if err := decodeJSON(w, r, &param); err != nil {
 		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, cfg.secret)
+	param.NewEmail = strings.TrimSpace(param.NewEmail)
+
+	user, err := cfg.queries.GetUserById(r.Context(), userId)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusUnauthorized)
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(param.Password)
+	if err := auth.CheckPasswordHash(param.CurrentPassword, user.HashedPassword); err != nil {
+		writeErrorResponse(w, errors.New("incorrect password"), http.StatusUnauthorized)
+		return
+	}
+
+	if param.NewEmail == user.Email {
+		writeErrorResponse(w, errors.New("new email is the same as the current one"), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cfg.queries.GetUserByEmail(r.Context(), param.NewEmail); err == nil {
+		writeErrorResponse(w, errors.New("email is already in use"), http.StatusConflict)
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	confirmToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusUnauthorized)
+		writeErrorResponse(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	newUserCred, err := cfg.queries.UpdateUserCredential(
-		r.Context(),
-		database.UpdateUserCredentialParams{
-			ID:             userId,
-			Email:          param.Email,
-			HashedPassword: hashedPassword,
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	if err := qtx.CancelPendingEmailChanges(r.Context(), userId); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	_, err = qtx.CreateEmailChange(r.Context(),
+		database.CreateEmailChangeParams{
+			TokenHash: auth.HashToken(confirmToken),
+			UserID:    userId,
+			NewEmail:  param.NewEmail,
+			ExpiresAt: time.Now().Add(emailChangeTTL),
 		},
 	)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusNotFound)
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
-	res := response{
-		Id:          newUserCred.ID,
-		CreatedAt:   newUserCred.CreatedAt,
-		UpdatedAt:   newUserCred.UpdatedAt,
-		Email:       newUserCred.Email,
-		IsChirpyRed: newUserCred.IsChirpyRed,
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
 	}
-	writeSuccessResponse(w, res, http.StatusOK)
+
+	err = cfg.mailer.Send(r.Context(), mailer.Message{
+		To:      param.NewEmail,
+		Subject: "Confirm your new Chirpy email",
+		Body: fmt.Sprintf("Someone asked to use this address for a Chirpy account.\n\n"+
+			"To confirm, send this token to %s/api/users/email/confirm within 24 hours:\n\n%s\n\n"+
+			"If this wasn't you, ignore this email.", cfg.baseURL, confirmToken),
+	})
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("sending confirmation email: %w", err), http.StatusBadGateway)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusAccepted)
 }
 
-func (cfg *apiConfig) handlerUpdateChirpyRed(w http.ResponseWriter, r *http.Request) {
+// handlerConfirmEmailChange applies a pending email change. The token is the
+// proof of ownership, so no access token is needed. The old address is told
+// about the change in case it wasn't the account owner who made it.
+func (cfg *apiConfig) handlerConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
 	type parameter struct {
-		Event string `json:"event"`
-		Data  struct {
-			UserId uuid.UUID `json:"user_id"`
-		} `json:"data"`
+		Token string `json:"token" validate:"required"`
+	}
+	type response struct {
+		Id    uuid.UUID `json:"id"`
+		Email string    `json:"email"`
+	}
+
+	param := parameter{}
+	if err := decodeJSON(w, r, &param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
+
+	change, err := qtx.ConfirmEmailChange(r.Context(), auth.HashToken(param.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, errors.New("invalid or expired token"), http.StatusBadRequest)
+			return
+		}
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	oldUser, err := qtx.GetUserById(r.Context(), change.UserID)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	user, err := qtx.UpdateUserEmail(r.Context(),
+		database.UpdateUserEmailParams{
+			ID:    change.UserID,
+			Email: change.NewEmail,
+		},
+	)
+	if err != nil {
+		if dberr.Classify(err) == dberr.UniqueViolation {
+			writeErrorResponse(w, errors.New("email is already in use"), http.StatusConflict)
+			return
+		}
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	err = cfg.mailer.Send(r.Context(), mailer.Message{
+		To:      oldUser.Email,
+		Subject: "Your Chirpy email was changed",
+		Body: fmt.Sprintf("The email on your Chirpy account was changed to %s.\n\n"+
+			"If you didn't make this change, contact support right away.", user.Email),
+	})
+	if err != nil {
+		log.Printf("Error notifying %s of email change: %s", oldUser.Email, err)
+	}
+
+	writeSuccessResponse(w, response{Id: user.ID, Email: user.Email}, http.StatusOK)
+}
+
+// handlerChangePassword replaces the password after re-checking the current
+// one. Every refresh token is revoked, signing out other sessions, and the
+// caller gets a fresh pair so their own session carries on.
+func (cfg *apiConfig) handlerChangePassword(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required"`
+	}
+	type response struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	token, err := auth.GetBearerToken(r.Header)
@@ -215,37 +355,142 @@ func (cfg *apiConfig) handlerUpdateChirpyRed(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if token != cfg.polka_key {
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
 		writeErrorResponse(w, err, http.StatusUnauthorized)
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
 	param := parameter{}
-	if err := decoder.Decode(&param); err != nil {
+	if err := decodeJSON(w, r, &param); err != nil {
 		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
-	if param.Event != "user.upgraded" {
-		writeSuccessResponse(w, struct{}{}, http.StatusNoContent)
+	user, err := cfg.queries.GetUserById(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if err := auth.CheckPasswordHash(param.CurrentPassword, user.HashedPassword); err != nil {
+		writeErrorResponse(w, errors.New("incorrect password"), http.StatusUnauthorized)
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(param.NewPassword)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	refreshTokenString, err := auth.MakeRefreshToken()
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.queries.WithTx(tx)
 
-	userId, err := uuid.Parse(param.Data.UserId.String())
+	_, err = qtx.UpdateUserPassword(r.Context(),
+		database.UpdateUserPasswordParams{
+			ID:             userId,
+			HashedPassword: hashedPassword,
+		},
+	)
 	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if err := qtx.RevokeAllUserTokens(r.Context(), userId); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	refreshToken, err := qtx.CreateRefreshToken(r.Context(),
+		database.CreateRefreshTokenParams{
+			Token:     refreshTokenString,
+			UserID:    userId,
+			ExpiresAt: time.Now().AddDate(0, 0, 60),
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeErrorResponse(w, err, dberr.Status(err))
+		return
+	}
+
+	jwt, err := auth.MakeJWT(user.ID, user.Role, cfg.secret, time.Hour)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, response{Token: jwt, RefreshToken: refreshToken.Token}, http.StatusOK)
+}
+
+// handlerUpdateHandle sets the caller's handle, which is how other users
+// mention them. Handles are case-insensitive and stored lowercase.
+func (cfg *apiConfig) handlerUpdateHandle(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Handle string `json:"handle" validate:"required,min=3,max=20"`
+	}
+	type response struct {
+		Id     uuid.UUID `json:"id"`
+		Handle string    `json:"handle"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	param := parameter{}
+	if err := decodeJSON(w, r, &param); err != nil {
 		writeErrorResponse(w, err, http.StatusBadRequest)
 		return
 	}
 
-	_, err = cfg.queries.UpgradeToChirpyRed(
-		r.Context(),
-		userId,
+	handle := strings.ToLower(param.Handle)
+	for i := 0; i < len(handle); i++ {
+		if !isHandleChar(handle[i]) {
+			writeErrorResponse(w, newValidationError(fieldError{
+				Field:   "handle",
+				Code:    "invalid",
+				Message: "handle may only contain letters, digits and underscores",
+			}), http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err := cfg.queries.UpdateUserHandle(r.Context(),
+		database.UpdateUserHandleParams{
+			ID:     userId,
+			Handle: sql.NullString{String: handle, Valid: true},
+		},
 	)
 	if err != nil {
-		writeErrorResponse(w, err, http.StatusNotFound)
+		writeErrorResponse(w, err, dberr.Status(err))
 		return
 	}
 
-	writeSuccessResponse(w, struct{}{}, http.StatusNoContent)
+	writeSuccessResponse(w, response{Id: user.ID, Handle: user.Handle.String}, http.StatusOK)
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/google/uuid"
+)
+
+// Chirp visibility levels. Unlisted chirps are readable by anyone with the
+// link and appear on the author's page, but stay off the timeline.
+const (
+	visibilityPublic    = "public"
+	visibilityUnlisted  = "unlisted"
+	visibilityFollowers = "followers"
+	visibilityPrivate   = "private"
+)
+
+var errChirpNotFound = errors.New("chirp not found")
+
+var validVisibilities = map[string]bool{
+	visibilityPublic:    true,
+	visibilityUnlisted:  true,
+	visibilityFollowers: true,
+	visibilityPrivate:   true,
+}
+
+// parseVisibility validates a requested visibility, defaulting to public.
+func parseVisibility(visibility string) (string, error) {
+	if visibility == "" {
+		return visibilityPublic, nil
+	}
+	if !validVisibilities[visibility] {
+		return "", fmt.Errorf("invalid visibility %q", visibility)
+	}
+	return visibility, nil
+}
+
+// chirpAudience returns the visibility that actually applies to a chirp:
+// a private account's public and unlisted chirps are followers-only.
+func chirpAudience(ctx context.Context, q *database.Queries, chirp database.Chirp) (string, error) {
+	if chirp.Visibility != visibilityPublic && chirp.Visibility != visibilityUnlisted {
+		return chirp.Visibility, nil
+	}
+
+	author, err := q.GetUserById(ctx, chirp.UserID)
+	if err != nil {
+		return "", err
+	}
+	if author.IsPrivate {
+		return visibilityFollowers, nil
+	}
+	return chirp.Visibility, nil
+}
+
+// canViewChirp reports whether viewerID, which may be anonymous, is in the
+// chirp's audience. It does not consider blocks or moderation.
+func canViewChirp(ctx context.Context, q *database.Queries, viewerID uuid.NullUUID, chirp database.Chirp) (bool, error) {
+	if viewerID.Valid && viewerID.UUID == chirp.UserID {
+		return true, nil
+	}
+
+	audience, err := chirpAudience(ctx, q, chirp)
+	if err != nil {
+		return false, err
+	}
+
+	switch audience {
+	case visibilityPublic, visibilityUnlisted:
+		return true, nil
+	case visibilityFollowers:
+		if !viewerID.Valid {
+			return false, nil
+		}
+		return q.IsFollowing(ctx,
+			database.IsFollowingParams{
+				FollowerID: viewerID.UUID,
+				FolloweeID: chirp.UserID,
+			},
+		)
+	default:
+		return false, nil
+	}
+}
+
+// getVisibleChirp loads a chirp on behalf of viewerID, which may be
+// anonymous. Chirps the viewer may not see are reported as errChirpNotFound
+// rather than revealing that they exist.
+func getVisibleChirp(ctx context.Context, q *database.Queries, viewerID uuid.NullUUID, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := q.GetChirpById(ctx, chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Chirp{}, errChirpNotFound
+		}
+		return database.Chirp{}, err
+	}
+
+	// Moderators' hidden chirps stay visible to their author only.
+	if chirp.HiddenAt.Valid && (!viewerID.Valid || viewerID.UUID != chirp.UserID) {
+		return database.Chirp{}, errChirpNotFound
+	}
+
+	if viewerID.Valid {
+		blocked, err := isBlockedEitherWay(ctx, q, viewerID.UUID, chirp.UserID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if blocked {
+			return database.Chirp{}, errChirpNotFound
+		}
+	}
+
+	visible, err := canViewChirp(ctx, q, viewerID, chirp)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !visible {
+		return database.Chirp{}, errChirpNotFound
+	}
+
+	return chirp, nil
+}
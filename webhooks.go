@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sanghun1Adam1Park/chirp/internal/auth"
+	"github.com/Sanghun1Adam1Park/chirp/internal/database"
+	"github.com/Sanghun1Adam1Park/chirp/internal/retry"
+	"github.com/Sanghun1Adam1Park/chirp/internal/webhook"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookEventChirpCreated = "chirp.created"
+	webhookEventChirpDeleted = "chirp.deleted"
+	webhookEventUserUpgraded = "user.upgraded"
+)
+
+const (
+	webhookDeliveryStatusPending   = "pending"
+	webhookDeliveryStatusDelivered = "delivered"
+	webhookDeliveryStatusDead      = "dead"
+)
+
+const (
+	webhookMaxAttempts      = 8
+	webhookBatchSize        = 20
+	webhookDeliveryTimeout  = 10 * time.Second
+	webhookLeaseMargin      = time.Minute
+	defaultDeliveryLogLimit = 50
+	maxDeliveryLogLimit     = 100
+)
+
+var supportedWebhookEvents = map[string]bool{
+	webhookEventChirpCreated: true,
+	webhookEventChirpDeleted: true,
+	webhookEventUserUpgraded: true,
+}
+
+type webhookSubscriptionResponse struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+}
+
+func (cfg *apiConfig) handlerCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Url    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	param := parameter{}
+	if err := decoder.Decode(&param); err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	target, err := webhook.ValidateURL(r.Context(), param.Url)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if len(param.Events) == 0 {
+		writeErrorResponse(w, errors.New("at least one event is required"), http.StatusBadRequest)
+		return
+	}
+	for _, event := range param.Events {
+		if !supportedWebhookEvents[event] {
+			writeErrorResponse(w, fmt.Errorf("unsupported event %q", event), http.StatusBadRequest)
+			return
+		}
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	subscription, err := cfg.queries.CreateWebhookSubscription(r.Context(),
+		database.CreateWebhookSubscriptionParams{
+			UserID: userId,
+			Url:    target.String(),
+			Secret: secret,
+			Events: param.Events,
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	res := webhookSubscriptionResponse{
+		Id:        subscription.ID,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+		Url:       subscription.Url,
+		Events:    subscription.Events,
+		Secret:    subscription.Secret,
+	}
+	writeSuccessResponse(w, res, http.StatusCreated)
+}
+
+func (cfg *apiConfig) handlerGetWebhooks(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	subscriptions, err := cfg.queries.GetWebhookSubscriptionsByUserId(r.Context(), userId)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]webhookSubscriptionResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		responses = append(responses, webhookSubscriptionResponse{
+			Id:        subscription.ID,
+			CreatedAt: subscription.CreatedAt,
+			UpdatedAt: subscription.UpdatedAt,
+			Url:       subscription.Url,
+			Events:    subscription.Events,
+		})
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+func (cfg *apiConfig) handlerDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := cfg.getOwnedWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.queries.DeleteWebhookSubscription(r.Context(), subscription.ID); err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeSuccessResponse(w, nil, http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Id             uuid.UUID  `json:"id"`
+		CreatedAt      time.Time  `json:"created_at"`
+		Event          string     `json:"event"`
+		Status         string     `json:"status"`
+		Attempts       int32      `json:"attempts"`
+		NextAttemptAt  *time.Time `json:"next_attempt_at"`
+		LastStatusCode *int32     `json:"last_status_code"`
+		LastError      *string    `json:"last_error"`
+		DeliveredAt    *time.Time `json:"delivered_at"`
+	}
+
+	limit := defaultDeliveryLogLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 1 || parsedLimit > maxDeliveryLogLimit {
+			writeErrorResponse(w, fmt.Errorf("invalid limit %q", limitParam), http.StatusBadRequest)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	subscription, ok := cfg.getOwnedWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := cfg.queries.GetWebhookDeliveriesBySubscriptionId(r.Context(),
+		database.GetWebhookDeliveriesBySubscriptionIdParams{
+			SubscriptionID: subscription.ID,
+			Limit:          int32(limit),
+		},
+	)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	responses := make([]response, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		res := response{
+			Id:        delivery.ID,
+			CreatedAt: delivery.CreatedAt,
+			Event:     delivery.Event,
+			Status:    delivery.Status,
+			Attempts:  delivery.Attempts,
+		}
+		if delivery.Status == webhookDeliveryStatusPending {
+			res.NextAttemptAt = &delivery.NextAttemptAt
+		}
+		if delivery.LastStatusCode.Valid {
+			res.LastStatusCode = &delivery.LastStatusCode.Int32
+		}
+		if delivery.LastError.Valid {
+			res.LastError = &delivery.LastError.String
+		}
+		if delivery.DeliveredAt.Valid {
+			res.DeliveredAt = &delivery.DeliveredAt.Time
+		}
+		responses = append(responses, res)
+	}
+
+	writeSuccessResponse(w, responses, http.StatusOK)
+}
+
+// getOwnedWebhookSubscription loads the subscription named by the {id} path
+// value and checks that it belongs to the caller. On failure it writes the
+// error response itself and returns false.
+func (cfg *apiConfig) getOwnedWebhookSubscription(w http.ResponseWriter, r *http.Request) (database.WebhookSubscription, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.WebhookSubscription{}, false
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		writeErrorResponse(w, err, http.StatusUnauthorized)
+		return database.WebhookSubscription{}, false
+	}
+
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		writeErrorResponse(w, fmt.Errorf("invalid id %q: %w", idStr, err), http.StatusBadRequest)
+		return database.WebhookSubscription{}, false
+	}
+
+	subscription, err := cfg.queries.GetWebhookSubscriptionById(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErrorResponse(w, fmt.Errorf("webhook not found"), http.StatusNotFound)
+			return database.WebhookSubscription{}, false
+		}
+		writeErrorResponse(w, err, http.StatusInternalServerError)
+		return database.WebhookSubscription{}, false
+	}
+
+	if subscription.UserID != userId {
+		writeErrorResponse(w, errors.New("webhook does not belong to user"), http.StatusForbidden)
+		return database.WebhookSubscription{}, false
+	}
+
+	return subscription, true
+}
+
+// enqueueWebhookEvent writes one outbox row per interested subscription. It
+// takes the caller's transaction-scoped queries so that the event is only
+// published if the change that produced it commits. A valid userID limits
+// delivery to that user's own subscriptions.
+func enqueueWebhookEvent(ctx context.Context, q *database.Queries, event string, userID uuid.NullUUID, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.EnqueueWebhookDeliveries(ctx,
+		database.EnqueueWebhookDeliveriesParams{
+			Event:   event,
+			Payload: payload,
+			UserID:  userID,
+		},
+	)
+	return err
+}
+
+// runWebhookDispatcher drains the outbox on every tick until ctx is done.
+func (cfg *apiConfig) runWebhookDispatcher(ctx context.Context, interval time.Duration) {
+	client := webhook.NewClient(webhookDeliveryTimeout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.dispatchWebhooks(ctx, client)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) dispatchWebhooks(ctx context.Context, client *http.Client) {
+	type envelope struct {
+		Id    uuid.UUID       `json:"id"`
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+
+	// Deliveries in a batch are sent one after another, so the lease has to
+	// cover every one of them timing out.
+	deliveries, err := cfg.queries.ClaimDueWebhookDeliveries(ctx,
+		database.ClaimDueWebhookDeliveriesParams{
+			LeaseUntil: time.Now().Add(webhookBatchSize*webhookDeliveryTimeout + webhookLeaseMargin),
+			RowLimit:   webhookBatchSize,
+		},
+	)
+	if err != nil {
+		log.Printf("Error claiming webhook deliveries: %s", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		body, err := json.Marshal(envelope{
+			Id:    delivery.ID,
+			Event: delivery.Event,
+			Data:  delivery.Payload,
+		})
+		if err != nil {
+			log.Printf("Error encoding webhook delivery %s: %s", delivery.ID, err)
+			continue
+		}
+
+		attempts := delivery.Attempts + 1
+		now := time.Now()
+		next := database.MarkWebhookDeliveryAttemptParams{
+			ID:              delivery.ID,
+			Status:          webhookDeliveryStatusDelivered,
+			Attempts:        attempts,
+			NextAttemptAt:   now,
+			ClaimedAttempts: delivery.Attempts,
+			LeasedUntil:     delivery.NextAttemptAt,
+		}
+
+		statusCode, err := webhook.Deliver(ctx, client, delivery.Url, delivery.Secret, delivery.ID.String(), delivery.Event, body)
+		if statusCode != 0 {
+			next.LastStatusCode = sql.NullInt32{Int32: int32(statusCode), Valid: true}
+		}
+		if err != nil {
+			next.LastError = sql.NullString{String: err.Error(), Valid: true}
+			next.Status = webhookDeliveryStatusPending
+			next.NextAttemptAt = now.Add(retry.Backoff(int(attempts)))
+			if attempts >= webhookMaxAttempts {
+				next.Status = webhookDeliveryStatusDead
+			}
+		} else {
+			next.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+		}
+
+		recorded, err := cfg.queries.MarkWebhookDeliveryAttempt(ctx, next)
+		if err != nil {
+			log.Printf("Error recording webhook delivery %s: %s", delivery.ID, err)
+			continue
+		}
+		if recorded == 0 {
+			log.Printf("Lease on webhook delivery %s expired before its attempt was recorded", delivery.ID)
+		}
+	}
+}